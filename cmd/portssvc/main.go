@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	// Embedded IANA time zone database allows to validate port time zones in images without it
+	_ "time/tzdata"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/danielfurman/ports-microservices/internal/portssvc"
@@ -20,8 +22,12 @@ func main() {
 		logrus.WithError(err).Fatal("Failed to read config from environment")
 	}
 
-	server := portssvc.NewServer(cfg)
-	if err := server.Serve(ctx); err != nil {
+	server, err := portssvc.NewServer(ctx, cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create ports server")
+	}
+
+	if err = server.Serve(ctx); err != nil {
 		logrus.WithError(err).Fatal("Ports server stopped")
 	}
 
@@ -0,0 +1,211 @@
+package ingestsvc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkpoint is a position in the ports file up to which all records were stored in Ports service or skipped.
+type checkpoint struct {
+	// Records is a number of committed records from the beginning of the file.
+	Records int64 `json:"records"`
+	// Key is the key of the last committed record. It is verified on resume to detect a changed ports file.
+	Key string `json:"key"`
+	// Errors is a number of committed records that were not stored. They count towards the error policy limit
+	// of the resumed run.
+	Errors int `json:"errors"`
+	// Time is when the checkpoint was saved.
+	Time time.Time `json:"time"`
+}
+
+// checkpointer tracks committed records of the ports file and periodically saves the checkpoint to the file.
+// Records are committed in the file order, although concurrent workers store them in arbitrary order. Dead letters
+// of the records that were not stored are written when the records are committed, so that they are in the file order
+// and a saved checkpoint never covers a record missing from the dead letter file. It is safe for concurrent use.
+type checkpointer struct {
+	// path is a path to the checkpoint file. Checkpoints are not saved if it is empty.
+	path     string
+	interval time.Duration
+	// resumed is the checkpoint committed before this run.
+	resumed     checkpoint
+	deadLetters *ingestErrors
+
+	mu        sync.Mutex
+	committed checkpoint
+	// completed are the records completed after the first uncommitted one, by the record positions.
+	completed map[int64]completedRecord
+	lastSave  time.Time
+}
+
+// completedRecord is a record that was stored, or that has a dead letter if it was not stored.
+type completedRecord struct {
+	key    string
+	letter *deadLetter
+}
+
+// readCheckpoint reads the checkpoint to resume from if resume is enabled. It returns the zero checkpoint,
+// which points to the file beginning, if resume is disabled or no checkpoint was saved.
+func (s Service) readCheckpoint() (checkpoint, error) {
+	if !s.cfg.Resume {
+		return checkpoint{}, nil
+	}
+
+	content, err := os.ReadFile(s.cfg.CheckpointFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.log.WithField("path", s.cfg.CheckpointFilePath).Info(
+			"No checkpoint to resume from - ingesting from the beginning")
+		return checkpoint{}, nil
+	}
+	if err != nil {
+		return checkpoint{}, fmt.Errorf("read checkpoint file: %w", err)
+	}
+	var cp checkpoint
+	if err = json.Unmarshal(content, &cp); err != nil {
+		return checkpoint{}, fmt.Errorf("decode checkpoint file: %w", err)
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"records": cp.Records,
+		"port-id": cp.Key,
+		"errors":  cp.Errors,
+	}).Info("Resuming ingestion from checkpoint")
+	return cp, nil
+}
+
+// newCheckpointer creates a checkpointer that starts from the resumed checkpoint and writes the dead letters
+// of the committed records.
+func (s Service) newCheckpointer(resumed checkpoint, deadLetters *ingestErrors) *checkpointer {
+	return &checkpointer{
+		path:        s.cfg.CheckpointFilePath,
+		interval:    s.cfg.CheckpointInterval,
+		resumed:     resumed,
+		deadLetters: deadLetters,
+		committed:   resumed,
+		completed:   make(map[int64]completedRecord),
+		lastSave:    time.Now(),
+	}
+}
+
+// commit writes the dead letters of the records up to the record at given position, counted from 1, and commits
+// the records.
+func (c *checkpointer) commit(position int64, key string, letters []deadLetter) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.deadLetters.write(letters...); err != nil {
+		return err
+	}
+	c.committed.Records, c.committed.Key = position, key
+	c.committed.Errors += len(letters)
+	return nil
+}
+
+// complete marks the record at given position, counted from 1, as completed. The letter is the dead letter
+// of the record if it was not stored. The records are committed when all records before them are completed.
+func (c *checkpointer) complete(position int64, key string, letter *deadLetter) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed[position] = completedRecord{key: key, letter: letter}
+	for {
+		next, ok := c.completed[c.committed.Records+1]
+		if !ok {
+			return nil
+		}
+		if next.letter != nil {
+			if err := c.deadLetters.write(*next.letter); err != nil {
+				return err
+			}
+			c.committed.Errors++
+		}
+		delete(c.completed, c.committed.Records+1)
+		c.committed.Records, c.committed.Key = c.committed.Records+1, next.key
+	}
+}
+
+// due returns true if the checkpoint should be saved.
+func (c *checkpointer) due() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.path != "" && time.Since(c.lastSave) >= c.interval
+}
+
+// save saves the committed checkpoint. The dead letter file is synced first, and the checkpoint file is replaced
+// atomically, so that a crash does not leave a partially written checkpoint.
+func (c *checkpointer) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.deadLetters.sync(); err != nil {
+		return err
+	}
+	c.committed.Time = time.Now().UTC()
+	content, err := json.Marshal(c.committed)
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+
+	tmpPath := c.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("create checkpoint file: %w", err)
+	}
+	if _, err = file.Write(content); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write checkpoint file: %w", err)
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("sync checkpoint file: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("close checkpoint file: %w", err)
+	}
+	if err = os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("replace checkpoint file: %w", err)
+	}
+	c.lastSave = time.Now()
+	return nil
+}
+
+// remove removes the checkpoint file after the whole ports file is ingested, so that the next run starts
+// from the beginning.
+func (c *checkpointer) remove() error {
+	if c.path == "" {
+		return nil
+	}
+	err := os.Remove(c.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove checkpoint file: %w", err)
+	}
+	return nil
+}
+
+// skipCommitted skips the records committed before this run. The key of the last skipped record must match
+// the checkpoint, otherwise the ports file was changed since the checkpoint was saved.
+func (s Service) skipCommitted(decoder *json.Decoder, committed checkpoint) error {
+	var key string
+	for i := int64(0); i < committed.Records; i++ {
+		if !decoder.More() {
+			return fmt.Errorf("ports file has fewer records than %v of checkpoint - was the file changed?",
+				committed.Records)
+		}
+		var err error
+		if key, _, err = s.decodeRecord(decoder); err != nil {
+			return err
+		}
+	}
+	if key != committed.Key {
+		return fmt.Errorf("record %v of ports file has key %v instead of %v of checkpoint - was the file changed?",
+			committed.Records, key, committed.Key)
+	}
+	return nil
+}
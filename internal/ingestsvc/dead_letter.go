@@ -0,0 +1,142 @@
+package ingestsvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+// deadLetter is a line of the dead letter file: a record of the ports file that was not stored.
+type deadLetter struct {
+	// Key is the key of the record in the ports file, i.e. the port ID.
+	Key string `json:"key"`
+	// Record is the port object as read from the ports file.
+	Record json.RawMessage `json:"record"`
+	// Code is the gRPC code returned by Ports service. It is empty if the record could not be decoded.
+	Code string `json:"code,omitempty"`
+	// Error is the reason why the record was not stored.
+	Error string `json:"error"`
+}
+
+// ingestErrors counts the records that were not stored, tells whether there were more of them than the error policy
+// allows, and writes their dead letters to the file if it is configured. It is safe for concurrent use, but the dead
+// letters are written only by the checkpointer, which orders them.
+type ingestErrors struct {
+	// maxCount is a maximum number of records that can be skipped, negative if there is no limit.
+	maxCount int
+
+	mu sync.Mutex
+	// count is a number of records that were not stored, including the records committed before the resumed run.
+	count int
+	// firstStoreErr is the first written dead letter of a record that Ports service did not store, nil if there is
+	// none.
+	firstStoreErr *deadLetter
+
+	// file is the dead letter file, nil if it is not configured.
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// newIngestErrors creates ingestErrors for the configured error policy, counting the errors of the resumed checkpoint.
+// The dead letter file is created, or truncated if it exists, so that it contains only the records of the current
+// run. The resumed run appends to the file of the previous run instead.
+func (s Service) newIngestErrors(resumed checkpoint) (*ingestErrors, error) {
+	errs := &ingestErrors{maxCount: s.maxErrors(), count: resumed.Errors}
+	if s.cfg.DeadLetterFilePath == "" {
+		return errs, nil
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resumed.Records > 0 {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(s.cfg.DeadLetterFilePath, flag, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("create dead letter file: %w", err)
+	}
+	errs.file, errs.encoder = file, json.NewEncoder(file)
+	return errs, nil
+}
+
+// maxErrors returns a maximum number of records that can be skipped, negative if there is no limit.
+func (s Service) maxErrors() int {
+	switch s.cfg.ErrorPolicy {
+	case ErrorPolicySkip:
+		return -1
+	case ErrorPolicyMaxErrors:
+		return s.cfg.MaxErrors
+	default:
+		return 0
+	}
+}
+
+// add counts the record that was not stored. Its dead letter is written when the record is committed.
+func (e *ingestErrors) add() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.count++
+}
+
+// exceeded returns true if more records were not stored than the error policy allows.
+func (e *ingestErrors) exceeded() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.maxCount >= 0 && e.count > e.maxCount
+}
+
+// write writes the dead letters to the file, if it is configured, and remembers the first store error.
+func (e *ingestErrors) write(letters ...deadLetter) error {
+	e.mu.Lock()
+	for i := range letters {
+		if e.firstStoreErr == nil && letters[i].Code != "" {
+			e.firstStoreErr = &letters[i]
+		}
+	}
+	e.mu.Unlock()
+
+	if e.encoder == nil {
+		return nil
+	}
+	for _, letter := range letters {
+		if err := e.encoder.Encode(letter); err != nil {
+			return fmt.Errorf("write record with key %v to dead letter file: %w", letter.Key, err)
+		}
+	}
+	return nil
+}
+
+// sync syncs the dead letter file, if it is configured.
+func (e *ingestErrors) sync() error {
+	if e.file == nil {
+		return nil
+	}
+	if err := e.file.Sync(); err != nil {
+		return fmt.Errorf("sync dead letter file: %w", err)
+	}
+	return nil
+}
+
+// close syncs and closes the dead letter file.
+func (e *ingestErrors) close() error {
+	if e.file == nil {
+		return nil
+	}
+	if err := e.sync(); err != nil {
+		_ = e.file.Close()
+		return err
+	}
+	return e.file.Close()
+}
+
+// storeDeadLetter returns the dead letter of the record that Ports service did not store.
+func storeDeadLetter(rec portRecord, code codes.Code, message string) deadLetter {
+	return deadLetter{
+		Key:    rec.key,
+		Record: rec.raw,
+		Code:   code.String(),
+		Error:  message,
+	}
+}
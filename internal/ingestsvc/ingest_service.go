@@ -5,14 +5,52 @@ package ingestsvc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"os"
+	"time"
 
 	"github.com/danielfurman/ports-microservices/internal/logs"
 	"github.com/danielfurman/ports-microservices/internal/portsclient"
 	"github.com/danielfurman/ports-microservices/internal/portssvc/portsgrpc"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// callerName identifies Ingest service in the history of the ports it stores.
+const callerName = "ingest-service"
+
+// Ingestion modes supported by Service.
+const (
+	// ModeUpsert stores the ports of the file, leaving other stored ports intact.
+	ModeUpsert = "upsert"
+	// ModeSync stores the ports of the file, and then deletes the stored ports that the file does not mention.
+	ModeSync = "sync"
+)
+
+// Error policies supported by Service. They decide what happens to the records of the ports file that cannot be
+// decoded or stored in Ports service.
+const (
+	// ErrorPolicyFailFast stops the ingestion at the first record that cannot be decoded or stored. Ports that were
+	// already sent over the same stream, or stored in parallel, are still stored.
+	ErrorPolicyFailFast = "fail-fast"
+	// ErrorPolicySkip skips all records that cannot be decoded or stored.
+	ErrorPolicySkip = "skip"
+	// ErrorPolicyMaxErrors skips up to Config.MaxErrors records that cannot be decoded or stored, and stops
+	// the ingestion like ErrorPolicyFailFast if there are more of them.
+	ErrorPolicyMaxErrors = "max-errors"
+)
+
+const (
+	// defaultSyncMaxDeleteRatio is used when Config.SyncMaxDeleteRatio is not set.
+	defaultSyncMaxDeleteRatio = 0.1
+	// defaultCheckpointInterval is used when Config.CheckpointInterval is not set.
+	defaultCheckpointInterval = 10 * time.Second
 )
 
 // Service is an Ingest service.
@@ -20,7 +58,9 @@ type Service struct {
 	cfg Config
 
 	portsClient portsclient.GRPC
-	log         *logrus.Entry
+	// limiter limits the rate of ports sent to Ports service.
+	limiter *rate.Limiter
+	log     *logrus.Entry
 }
 
 // Config is a config for Ingest service.
@@ -29,6 +69,47 @@ type Config struct {
 	PortsFilePath string `env:"PORTS_FILE_PATH,notEmpty"`
 	// PortsServiceAddress is a TCP address of the Ports service. Env var: PORTS_SVC_ADDRESS. Default: ":9090".
 	PortsServiceAddress string `env:"PORTS_SVC_ADDRESS" envDefault:":9090"`
+	// PortsCallTimeout is a deadline of a call to Ports service, including retries, and of opening a stream.
+	// Env var: PORTS_SVC_CALL_TIMEOUT. Default: "10s".
+	PortsCallTimeout time.Duration `env:"PORTS_SVC_CALL_TIMEOUT" envDefault:"10s"`
+	// PortsMaxAttempts is a maximum number of attempts of a query, or of storing ports, to Ports service that
+	// is unavailable, at most 5. Env var: PORTS_SVC_MAX_ATTEMPTS. Default: "4".
+	PortsMaxAttempts int `env:"PORTS_SVC_MAX_ATTEMPTS" envDefault:"4"`
+	// PortsWaitForReady makes the calls wait until Ports service is ready, e.g. when both services are starting.
+	// Env var: PORTS_SVC_WAIT_FOR_READY. Default: "true".
+	PortsWaitForReady bool `env:"PORTS_SVC_WAIT_FOR_READY" envDefault:"true"`
+	// Mode is an ingestion mode: "upsert" or "sync". Env var: INGEST_MODE. Default: "upsert".
+	Mode string `env:"INGEST_MODE" envDefault:"upsert"`
+	// SyncPurge makes "sync" mode purge the ports absent from the file. Otherwise they are soft-deleted, so that they
+	// can be restored. Env var: SYNC_PURGE. Default: "false".
+	SyncPurge bool `env:"SYNC_PURGE"`
+	// SyncMaxDeleteRatio is a maximum share of the stored ports, in range (0, 1], that "sync" mode can delete.
+	// A file that would delete more ports is considered truncated, and it is refused before any port is changed.
+	// Env var: SYNC_MAX_DELETE_RATIO. Default: "0.1".
+	SyncMaxDeleteRatio float64 `env:"SYNC_MAX_DELETE_RATIO" envDefault:"0.1"`
+	// Workers is a maximum number of ports stored in parallel. A single worker sends the ports sequentially over
+	// one stream, more workers send them with parallel calls. Env var: INGEST_WORKERS. Default: "1".
+	Workers int `env:"INGEST_WORKERS" envDefault:"1"`
+	// RateLimit is a maximum number of ports sent to Ports service per second. Zero means no limit.
+	// Env var: INGEST_RATE_LIMIT.
+	RateLimit float64 `env:"INGEST_RATE_LIMIT"`
+	// ErrorPolicy is an error policy: "fail-fast", "skip" or "max-errors". Env var: INGEST_ERROR_POLICY.
+	// Default: "fail-fast".
+	ErrorPolicy string `env:"INGEST_ERROR_POLICY" envDefault:"fail-fast"`
+	// MaxErrors is a maximum number of records skipped by "max-errors" policy. Env var: INGEST_MAX_ERRORS.
+	MaxErrors int `env:"INGEST_MAX_ERRORS"`
+	// DeadLetterFilePath is a path to the JSON Lines file which the records that were not stored are written to,
+	// with their key and error, in the file order. The file is truncated on every run, except a resumed one which
+	// appends to it. Env var: DEAD_LETTER_FILE_PATH. Optional.
+	DeadLetterFilePath string `env:"DEAD_LETTER_FILE_PATH"`
+	// CheckpointFilePath is a path to the file which the position of the ingestion is periodically saved to,
+	// so that it can be resumed. Checkpoints are not saved if it is empty. Env var: CHECKPOINT_FILE_PATH. Optional.
+	CheckpointFilePath string `env:"CHECKPOINT_FILE_PATH"`
+	// CheckpointInterval is an interval of saving checkpoints. Env var: CHECKPOINT_INTERVAL. Default: "10s".
+	CheckpointInterval time.Duration `env:"CHECKPOINT_INTERVAL" envDefault:"10s"`
+	// Resume makes the ingestion skip the records up to the checkpoint saved by the previous run. The ingestion
+	// starts from the beginning if there is no checkpoint. Env var: INGEST_RESUME. Default: "false".
+	Resume bool `env:"INGEST_RESUME"`
 }
 
 // NewService creates new Ingest service with given configuration.
@@ -37,7 +118,57 @@ func NewService(cfg Config) (Service, error) {
 	log := logs.NewLogger("ingest-service")
 	log.WithField("config", fmt.Sprintf("%+v", cfg)).Debug("Creating ingest service")
 
-	client, err := portsclient.NewGRPC(cfg.PortsServiceAddress)
+	switch cfg.Mode {
+	case ModeUpsert, ModeSync, "":
+	default:
+		return Service{}, fmt.Errorf("unknown ingest mode %q", cfg.Mode)
+	}
+	if cfg.SyncMaxDeleteRatio == 0 {
+		cfg.SyncMaxDeleteRatio = defaultSyncMaxDeleteRatio
+	}
+	if cfg.SyncMaxDeleteRatio < 0 || cfg.SyncMaxDeleteRatio > 1 {
+		return Service{}, fmt.Errorf("sync max delete ratio %v is not in range (0, 1]", cfg.SyncMaxDeleteRatio)
+	}
+	switch cfg.ErrorPolicy {
+	case ErrorPolicyFailFast, ErrorPolicySkip, "":
+	case ErrorPolicyMaxErrors:
+		if cfg.MaxErrors < 0 {
+			return Service{}, fmt.Errorf("max errors %v must not be negative", cfg.MaxErrors)
+		}
+	default:
+		return Service{}, fmt.Errorf("unknown error policy %q", cfg.ErrorPolicy)
+	}
+	if cfg.CheckpointInterval == 0 {
+		cfg.CheckpointInterval = defaultCheckpointInterval
+	}
+	if cfg.CheckpointInterval < 0 {
+		return Service{}, fmt.Errorf("checkpoint interval %v must be positive", cfg.CheckpointInterval)
+	}
+	if cfg.Resume && cfg.CheckpointFilePath == "" {
+		return Service{}, errors.New("resume requires checkpoint file path")
+	}
+	if cfg.Workers == 0 {
+		cfg.Workers = 1
+	}
+	if cfg.Workers < 0 {
+		return Service{}, fmt.Errorf("number of workers %v must be positive", cfg.Workers)
+	}
+	if cfg.RateLimit < 0 {
+		return Service{}, fmt.Errorf("rate limit %v must not be negative", cfg.RateLimit)
+	}
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if cfg.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), 1)
+	}
+
+	if cfg.PortsMaxAttempts == 0 {
+		cfg.PortsMaxAttempts = portsclient.DefaultMaxAttempts
+	}
+	client, err := portsclient.NewGRPC(cfg.PortsServiceAddress, portsclient.Options{
+		CallTimeout:  cfg.PortsCallTimeout,
+		MaxAttempts:  cfg.PortsMaxAttempts,
+		WaitForReady: cfg.PortsWaitForReady,
+	})
 	if err != nil {
 		return Service{}, fmt.Errorf("new ports gRPC client: %w", err)
 	}
@@ -45,6 +176,7 @@ func NewService(cfg Config) (Service, error) {
 	return Service{
 		cfg:         cfg,
 		portsClient: client,
+		limiter:     limiter,
 		log:         log,
 	}, nil
 }
@@ -53,6 +185,16 @@ func NewService(cfg Config) (Service, error) {
 //
 // The example JSON file with a format expected by the service is located in ./testdata/ports.json.
 // Resources are read from the file one-by-one with a stream to reduce memory consumption and support large files.
+// They are sent to Ports service over a single client-side stream, or with parallel calls of configured number
+// of workers. The configured error policy decides whether records that cannot be decoded or stored stop
+// the ingestion or are skipped. Either way they are written to the dead letter file, if it is configured, in the file
+// order and before the checkpoint covers them.
+// In sync mode, the stored ports that the file does not mention are deleted after all ports of the file are stored.
+// If the checkpoint file is configured, the position of the ingestion is saved to it periodically and when Run fails
+// or is stopped, and the checkpoint is removed when Run succeeds. Ports stored after the last checkpoint are sent
+// again on resume.
+// Ports service leaves ports with unchanged content as they are, and Run logs how many ports were created, updated
+// and unchanged.
 // Run can be stopped by context cancel/timeout.
 // This function is meant to be called only once, because it closes Ports client connection.
 func (s Service) Run(ctx context.Context) (err error) {
@@ -74,25 +216,290 @@ func (s Service) Run(ctx context.Context) (err error) {
 		}
 	}()
 
-	return s.decodeAndIngestPorts(ctx, file)
+	resumed, err := s.readCheckpoint()
+	if err != nil {
+		return err
+	}
+	errs, err := s.newIngestErrors(resumed)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cErr := errs.close()
+		if cErr != nil && err == nil {
+			err = fmt.Errorf("failed to close dead letter file: %w", cErr)
+		}
+	}()
+	cp := s.newCheckpointer(resumed, errs)
+
+	ctx = portsclient.WithCaller(ctx, callerName)
+	if s.cfg.Mode == ModeSync {
+		err = s.syncPorts(ctx, file, errs, cp)
+	} else {
+		err = s.decodeAndIngestPorts(ctx, file, errs, cp)
+	}
+	if err != nil {
+		if cpErr := cp.save(); cpErr != nil {
+			s.log.WithError(cpErr).Error("Failed to save checkpoint")
+		}
+		return err
+	}
+	return cp.remove()
+}
+
+// syncPorts ingests the ports and deletes the stored ports that the file does not mention. The ports to delete are
+// found before the ingestion, so that a truncated file is refused before any port is changed. Ports stored by others
+// during the ingestion are not deleted.
+func (s Service) syncPorts(ctx context.Context, file io.ReadSeeker, errs *ingestErrors, cp *checkpointer) error {
+	fileIDs, err := s.readPortIDs(file)
+	if err != nil {
+		return fmt.Errorf("read port IDs: %w", err)
+	}
+	stale, storedCount, err := s.stalePortIDs(ctx, fileIDs)
+	if err != nil {
+		return fmt.Errorf("find stale ports: %w", err)
+	}
+	if float64(len(stale)) > s.cfg.SyncMaxDeleteRatio*float64(storedCount) {
+		return fmt.Errorf(
+			"sync would delete %v of %v stored ports, more than max delete ratio %v allows - is the file truncated?",
+			len(stale), storedCount, s.cfg.SyncMaxDeleteRatio,
+		)
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind ports file: %w", err)
+	}
+	if err = s.decodeAndIngestPorts(ctx, file, errs, cp); err != nil {
+		return err
+	}
+	return s.deletePorts(ctx, stale)
 }
 
-func (s Service) decodeAndIngestPorts(ctx context.Context, reader io.Reader) error {
+// readPortIDs returns the set of IDs of the ports in the file, including the ports that cannot be decoded, so that
+// they are not deleted.
+func (s Service) readPortIDs(reader io.Reader) (map[string]struct{}, error) {
 	decoder := json.NewDecoder(reader)
+	if err := s.readOpeningBracket(decoder); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]struct{})
+	for decoder.More() {
+		portKey, _, err := s.decodeRecord(decoder)
+		if err != nil {
+			return nil, err
+		}
+		ids[portKey] = struct{}{}
+	}
+	return ids, nil
+}
+
+// stalePortIDs returns IDs of the stored ports that are not in given set, and the number of all stored ports.
+func (s Service) stalePortIDs(ctx context.Context, fileIDs map[string]struct{}) ([]string, int, error) {
+	it, err := s.portsClient.StreamPorts(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		stale       []string
+		storedCount int
+	)
+	for it.Next() {
+		storedCount++
+		if _, ok := fileIDs[it.Port().GetId()]; !ok {
+			stale = append(stale, it.Port().GetId())
+		}
+	}
+	return stale, storedCount, it.Err()
+}
+
+// deletePorts deletes the ports with given IDs. Ports that were deleted in the meantime are skipped.
+func (s Service) deletePorts(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		s.log.WithFields(logrus.Fields{
+			"port-id": id,
+			"purge":   s.cfg.SyncPurge,
+		}).Debug("Deleting stale port")
+		err := s.portsClient.DeletePort(ctx, id, s.cfg.SyncPurge)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return fmt.Errorf("delete stale port with ID %v: %w", id, err)
+		}
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"deleted-count": len(ids),
+		"purge":         s.cfg.SyncPurge,
+	}).Info("Stale ports deleted")
+	return nil
+}
+
+func (s Service) decodeAndIngestPorts(ctx context.Context, file io.Reader, errs *ingestErrors, cp *checkpointer) error {
+	decoder := json.NewDecoder(file)
 
 	err := s.readOpeningBracket(decoder)
 	if err != nil {
 		return err
 	}
+	if err = s.skipCommitted(decoder, cp.resumed); err != nil {
+		return err
+	}
+
+	var summary *portsgrpc.StorePortsResponse
+	if s.cfg.Workers > 1 {
+		summary, err = s.storePortsConcurrently(ctx, decoder, errs, cp)
+	} else {
+		summary, err = s.storePortsSequentially(ctx, decoder, errs, cp)
+	}
+	if err != nil {
+		return err
+	}
+	return s.checkSummary(summary, errs)
+}
+
+// storeBatchSize is a maximum number of records of a batch. Records of the batch are kept in memory until the batch
+// is committed, so that the dead letters of the records that were not stored can be written. Ports service lists
+// the errors of at most that many ports of a stream, so the errors of all ports of the batch are listed.
+const storeBatchSize = portsgrpc.StorePortsMaxErrors
+
+// storeBatch is a batch of records whose ports are sent over a single stream.
+type storeBatch struct {
+	stream *portsclient.StorePortsStream
+	// records are the records of the batch in the file order, including the records that cannot be decoded.
+	records []portRecord
+	// attempts is a number of the streams that the ports of the batch were sent over.
+	attempts int
+}
+
+// storePortsSequentially decodes the ports and sends them over a single stream. Ports service confirms that the ports
+// were stored only when the stream is closed, so the stream is closed and opened again whenever a checkpoint is due
+// or the batch is full. Sending stops after the batch in which more ports were not stored than the error policy
+// allows.
+func (s Service) storePortsSequentially(
+	ctx context.Context, decoder *json.Decoder, errs *ingestErrors, cp *checkpointer,
+) (*portsgrpc.StorePortsResponse, error) {
+	// Cancelling the context aborts the stream if decoding fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batch, err := s.openBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	summary := &portsgrpc.StorePortsResponse{}
+	position := cp.resumed.Records
 	for decoder.More() {
-		err = s.decodeAndIngestPort(ctx, decoder)
+		rec, err := s.decodePort(decoder)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		position++
+
+		// The batch is committed before the ingestion is stopped by the error policy, so that the dead letter
+		// file contains the record that stopped it
+		var policyErr error
+		batch.records = append(batch.records, rec)
+		if rec.letter != nil {
+			policyErr = s.addDecodeError(rec, errs)
+		} else if err = s.sendPort(ctx, batch.stream, rec); err != nil {
+			if err = s.retryBatch(ctx, batch, err); err != nil {
+				return nil, err
+			}
+		}
+		if policyErr == nil && len(batch.records) < storeBatchSize && !cp.due() {
+			continue
+		}
+
+		if err = s.commitBatch(ctx, batch, position, summary, errs, cp); err != nil {
+			return nil, err
+		}
+		if policyErr != nil {
+			return nil, policyErr
+		}
+		if errs.exceeded() {
+			// The summary tells which ports were not stored
+			return summary, nil
+		}
+		if cp.due() {
+			if err = cp.save(); err != nil {
+				return nil, err
+			}
+		}
+		if batch, err = s.openBatch(ctx); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	if err = s.commitBatch(ctx, batch, position, summary, errs, cp); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// openBatch opens the stream of a new batch.
+func (s Service) openBatch(ctx context.Context) (*storeBatch, error) {
+	stream, err := s.portsClient.StorePorts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open store ports stream: %w", err)
+	}
+	return &storeBatch{stream: stream, attempts: 1}, nil
+}
+
+// commitBatch closes the stream of the batch, adds its results to the summary of the ports sent over previous
+// streams, and commits the records of the batch up to given position with the dead letters of the records
+// that were not stored.
+func (s Service) commitBatch(
+	ctx context.Context, batch *storeBatch, position int64, summary *portsgrpc.StorePortsResponse, errs *ingestErrors,
+	cp *checkpointer,
+) error {
+	result, err := batch.stream.CloseAndRecv()
+	for err != nil {
+		if err = s.retryBatch(ctx, batch, err); err != nil {
+			return fmt.Errorf("store ports in ports service: %w", err)
+		}
+		result, err = batch.stream.CloseAndRecv()
+	}
+	summary.StoredCount += result.GetStoredCount()
+	summary.CreatedCount += result.GetCreatedCount()
+	summary.UpdatedCount += result.GetUpdatedCount()
+	summary.UnchangedCount += result.GetUnchangedCount()
+	summary.RejectedCount += result.GetRejectedCount()
+	summary.FailedCount += result.GetFailedCount()
+	if len(batch.records) == 0 {
+		return nil
+	}
+	// Records that are not stored but not listed could not be written to the dead letter file, nor skipped
+	// by the checkpoint, so the ingestion cannot continue without losing them
+	unlisted := result.GetRejectedCount() + result.GetFailedCount() - int64(len(result.GetErrors()))
+	if result.GetErrorsTruncated() || unlisted > 0 {
+		return fmt.Errorf("ports service did not list errors of %v ports not stored", unlisted)
+	}
+
+	// Records that could not be decoded were not sent, so they are not counted by the index
+	var (
+		letters   []deadLetter
+		index     int64
+		storeErrs = result.GetErrors()
+	)
+	for _, rec := range batch.records {
+		if rec.letter != nil {
+			letters = append(letters, *rec.letter)
+			continue
+		}
+		if len(storeErrs) > 0 && storeErrs[0].GetIndex() == index {
+			letter := storeDeadLetter(rec, codes.Code(storeErrs[0].GetCode()), storeErrs[0].GetMessage())
+			s.addStoreError(letter, errs)
+			letters = append(letters, letter)
+			storeErrs = storeErrs[1:]
+		}
+		index++
+	}
+	if len(storeErrs) > 0 {
+		return fmt.Errorf("port with ID %v not found in sent ports at index %v",
+			storeErrs[0].GetPortId(), storeErrs[0].GetIndex())
+	}
+	return cp.commit(position, batch.records[len(batch.records)-1].key, letters)
 }
 
 func (s Service) readOpeningBracket(decoder *json.Decoder) error {
@@ -107,32 +514,174 @@ func (s Service) readOpeningBracket(decoder *json.Decoder) error {
 	return nil
 }
 
-func (s Service) decodeAndIngestPort(ctx context.Context, decoder *json.Decoder) error {
+// retryBatch sends the ports of the batch again over a new stream if the stream failed with Unavailable code,
+// after a backoff like the one of the retried calls. Ports service stores the same ports again, so the retry
+// is safe. It returns the error if the stream cannot be retried.
+func (s Service) retryBatch(ctx context.Context, batch *storeBatch, err error) error {
+	for isUnavailable(err) && batch.attempts < s.cfg.PortsMaxAttempts {
+		s.log.WithError(err).WithField("attempt", batch.attempts+1).Warn("Retrying to store ports in ports service")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryBackoff(batch.attempts)):
+		}
+		batch.attempts++
+
+		if batch.stream, err = s.portsClient.StorePorts(ctx); err != nil {
+			err = fmt.Errorf("open store ports stream: %w", err)
+			continue
+		}
+		err = nil
+		for _, rec := range batch.records {
+			if rec.port == nil {
+				continue
+			}
+			if err = s.sendPort(ctx, batch.stream, rec); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// retryBackoff returns a randomized exponential backoff before the attempt following given number of attempts,
+// computed like the backoff of the calls retried by the Ports client.
+func retryBackoff(attempts int) time.Duration {
+	backoff := math.Min(
+		float64(portsclient.DefaultInitialBackoff)*math.Pow(portsclient.DefaultBackoffMultiplier, float64(attempts-1)),
+		float64(portsclient.DefaultMaxBackoff),
+	)
+	return time.Duration(rand.Int63n(int64(backoff) + 1)) //nolint:gosec // jitter does not need secure randomness
+}
+
+// isUnavailable returns true if the error has gRPC Unavailable code.
+func isUnavailable(err error) bool {
+	var st interface{ GRPCStatus() *status.Status }
+	return errors.As(err, &st) && st.GRPCStatus().Code() == codes.Unavailable
+}
+
+// sendPort sends the port of the record over the stream when the rate limiter allows it.
+func (s Service) sendPort(ctx context.Context, stream *portsclient.StorePortsStream, rec portRecord) error {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("wait for rate limiter: %w", err)
+	}
+
+	s.log.WithField("port-id", rec.key).Debug("Sending port to ports service")
+	if err := stream.Send(rec.port); err != nil {
+		return fmt.Errorf("send port with ID %v to ports service: %w", rec.key, err)
+	}
+	return nil
+}
+
+// portRecord is a record of the ports file.
+type portRecord struct {
+	key string
+	raw json.RawMessage
+	// port is the decoded port, nil if the record cannot be decoded.
+	port *portsgrpc.Port
+	// letter is the dead letter of the record that cannot be decoded, nil otherwise.
+	letter *deadLetter
+}
+
+// decodePort decodes the next record. A record that is valid JSON but not a valid port object gets a dead letter.
+// Invalid JSON always stops the decoding.
+func (s Service) decodePort(decoder *json.Decoder) (portRecord, error) {
+	portKey, raw, err := s.decodeRecord(decoder)
+	if err != nil {
+		return portRecord{}, err
+	}
+
+	rec := portRecord{key: portKey, raw: raw}
+	port, err := unmarshalPort(raw)
+	if err != nil {
+		err = fmt.Errorf("decode port object with key %v: %w", portKey, err)
+		rec.letter = &deadLetter{Key: portKey, Record: raw, Error: err.Error()}
+		return rec, nil
+	}
+	rec.port = portToPayload(port, portKey)
+	return rec, nil
+}
+
+// addDecodeError counts the record that cannot be decoded. It returns an error if the error policy does not allow
+// to skip it.
+func (s Service) addDecodeError(rec portRecord, errs *ingestErrors) error {
+	errs.add()
+	if errs.exceeded() {
+		return errors.New(rec.letter.Error)
+	}
+	s.log.WithError(errors.New(rec.letter.Error)).WithField("port-id", rec.key).Warn(
+		"Skipping port that cannot be decoded")
+	return nil
+}
+
+// addStoreError counts the record that Ports service did not store.
+func (s Service) addStoreError(letter deadLetter, errs *ingestErrors) {
+	errs.add()
+	s.log.WithFields(logrus.Fields{
+		"port-id": letter.Key,
+		"code":    letter.Code,
+		"error":   letter.Error,
+	}).Warn("Port not stored in ports service")
+}
+
+// decodeRecord decodes the next port key and raw port object.
+func (s Service) decodeRecord(decoder *json.Decoder) (string, json.RawMessage, error) {
 	portKeyT, err := decoder.Token()
 	if err != nil {
-		return fmt.Errorf("decoder.Token(): %w", err)
+		return "", nil, fmt.Errorf("decoder.Token(): %w", err)
 	}
 
 	portKey, ok := portKeyT.(string)
 	if !ok {
-		return fmt.Errorf("port key is expected to be string, got %+#v", portKey)
+		return "", nil, fmt.Errorf("port key is expected to be string, got %+#v", portKey)
 	}
 
-	var port Port
-	err = decoder.Decode(&port)
+	var record json.RawMessage
+	err = decoder.Decode(&record)
 	if err != nil {
-		return fmt.Errorf("decode port object: %w", err)
+		return "", nil, fmt.Errorf("decode port object: %w", err)
 	}
+	return portKey, record, nil
+}
 
-	s.log.WithField("port-id", portKey).Debug("Storing port in ports service")
-	err = s.portsClient.StorePort(ctx, portToPayload(port, portKey))
-	if err != nil {
-		return fmt.Errorf("store port with ID %v in ports service: %w", portKey, err)
+func unmarshalPort(record json.RawMessage) (Port, error) {
+	var port Port
+	err := json.Unmarshal(record, &port)
+	return port, err
+}
+
+// checkSummary logs the results of the ingestion and returns an error if more records were not stored than the error
+// policy allows, including the records committed before the resumed run.
+func (s Service) checkSummary(summary *portsgrpc.StorePortsResponse, errs *ingestErrors) error {
+	s.log.WithFields(logrus.Fields{
+		"stored-count":     summary.GetStoredCount(),
+		"created-count":    summary.GetCreatedCount(),
+		"updated-count":    summary.GetUpdatedCount(),
+		"unchanged-count":  summary.GetUnchangedCount(),
+		"rejected-count":   summary.GetRejectedCount(),
+		"failed-count":     summary.GetFailedCount(),
+		"not-stored-count": errs.count,
+	}).Info("Ports ingested")
+
+	// Records that cannot be decoded stop the ingestion when they exceed the limit, so only the records not stored
+	// by Ports service or committed before the resumed run can exceed it here
+	if !errs.exceeded() {
+		return nil
 	}
-	return nil
+	err := fmt.Errorf(
+		"%v records were not stored, more than the error policy allows: ports service rejected %v and failed "+
+			"to store %v ports", errs.count, summary.GetRejectedCount(), summary.GetFailedCount(),
+	)
+	if first := errs.firstStoreErr; first != nil {
+		err = fmt.Errorf("%w, first error on port with ID %v: %v", err, first.Key, first.Error)
+	}
+	return err
 }
 
-// Port models a JSON representation of the port.
+// Port models a JSON representation of the port. Coordinates are [longitude, latitude] of the port location.
 type Port struct {
 	Name        string    `json:"name"`
 	City        string    `json:"city"`
@@ -146,18 +695,29 @@ type Port struct {
 	Code        string    `json:"code"`
 }
 
+// portToPayload converts the port to payload. Coordinates that are not a [longitude, latitude] pair are sent
+// in the legacy field, so that Ports service reports them as invalid.
 func portToPayload(p Port, portID string) *portsgrpc.Port {
-	return &portsgrpc.Port{
-		Id:          portID,
-		Name:        p.Name,
-		City:        p.City,
-		Country:     p.Country,
-		Alias:       p.Alias,
-		Regions:     p.Regions,
-		Coordinates: p.Coordinates,
-		Province:    p.Province,
-		Timezone:    p.Timezone,
-		Unlocs:      p.Unlocs,
-		Code:        p.Code,
+	payload := &portsgrpc.Port{
+		Id:       portID,
+		Name:     p.Name,
+		City:     p.City,
+		Country:  p.Country,
+		Alias:    p.Alias,
+		Regions:  p.Regions,
+		Province: p.Province,
+		Timezone: p.Timezone,
+		Unlocs:   p.Unlocs,
+		Code:     p.Code,
+	}
+
+	if len(p.Coordinates) == 2 {
+		payload.Location = &portsgrpc.LatLng{
+			Latitude:  p.Coordinates[1],
+			Longitude: p.Coordinates[0],
+		}
+	} else {
+		payload.Coordinates = p.Coordinates //nolint:staticcheck // legacy field reports invalid coordinates
 	}
+	return payload
 }
@@ -1,10 +1,19 @@
 package ingestsvc_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/danielfurman/ports-microservices/internal/ingestsvc"
 	"github.com/danielfurman/ports-microservices/internal/portsclient"
@@ -12,6 +21,9 @@ import (
 	"github.com/danielfurman/ports-microservices/internal/portssvc/portsgrpc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -35,10 +47,12 @@ func TestService_Run(t *testing.T) {
 					Alias:       []string{"foo-alias", "bar-alias"},
 					Regions:     []string{"foo-region", "bar-region"},
 					Coordinates: []float64{55.5136433, 25.4052165},
+					Location:    &portsgrpc.LatLng{Latitude: 25.4052165, Longitude: 55.5136433},
 					Province:    "Ajman",
 					Timezone:    "Asia/Dubai",
 					Unlocs:      []string{"AEAJM"},
 					Code:        "52000",
+					Version:     1,
 				},
 				"AEAUH": {
 					Id:          "AEAUH",
@@ -48,10 +62,12 @@ func TestService_Run(t *testing.T) {
 					Alias:       nil,
 					Regions:     nil,
 					Coordinates: []float64{54.37, 24.47},
+					Location:    &portsgrpc.LatLng{Latitude: 24.47, Longitude: 54.37},
 					Province:    "Abu Z¸aby [Abu Dhabi]",
 					Timezone:    "Asia/Dubai",
 					Unlocs:      []string{"AEAUH"},
 					Code:        "52001",
+					Version:     1,
 				},
 				"AEDXB": {
 					Id:          "AEDXB",
@@ -61,31 +77,186 @@ func TestService_Run(t *testing.T) {
 					Alias:       nil,
 					Regions:     nil,
 					Coordinates: []float64{55.27, 25.25},
+					Location:    &portsgrpc.LatLng{Latitude: 25.25, Longitude: 55.27},
 					Province:    "Dubayy [Dubai]",
 					Timezone:    "Asia/Dubai",
 					Unlocs:      []string{"AEDXB"},
 					Code:        "52005",
+					Version:     1,
+				},
+			},
+		}, {
+			name:          "file with invalid port",
+			filePath:      filepath.Join("testdata", "invalid-port.json"),
+			expectedError: true,
+			expectedPorts: map[string]*portsgrpc.Port{
+				"AEAJM": {
+					Id:          "AEAJM",
+					Name:        "Ajman",
+					City:        "Ajman",
+					Country:     "United Arab Emirates",
+					Coordinates: []float64{55.5136433, 25.4052165},
+					Location:    &portsgrpc.LatLng{Latitude: 25.4052165, Longitude: 55.5136433},
+					Province:    "Ajman",
+					Timezone:    "Asia/Dubai",
+					Unlocs:      []string{"AEAJM"},
+					Code:        "52000",
+					Version:     1,
+				},
+				"AEDXB": {
+					Id:          "AEDXB",
+					Name:        "Dubai",
+					City:        "Dubai",
+					Country:     "United Arab Emirates",
+					Coordinates: []float64{55.27, 25.25},
+					Location:    &portsgrpc.LatLng{Latitude: 25.25, Longitude: 55.27},
+					Province:    "Dubayy [Dubai]",
+					Timezone:    "Asia/Dubai",
+					Unlocs:      []string{"AEDXB"},
+					Code:        "52005",
+					Version:     1,
 				},
 			},
 		},
 	}
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Given
-			ctx, cancel := context.WithCancel(context.Background())
-			server := portssvc.NewServer(portssvc.Config{GRPCServerAddress: ":0"})
-			go func() {
-				err := server.Serve(ctx)
+		for _, workers := range []int{1, 4} {
+			t.Run(fmt.Sprintf("%v with %v workers", tt.name, workers), func(t *testing.T) {
+				// Given
+				ctx := context.Background()
+				address, client := startServer(t)
+
+				s, err := ingestsvc.NewService(ingestsvc.Config{
+					PortsFilePath:       tt.filePath,
+					PortsServiceAddress: address,
+					Workers:             workers,
+				})
+				require.NoError(t, err)
+
+				// When
+				err = s.Run(ctx)
+
+				// Then
+				if tt.expectedError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+
+				ports, err := client.ListPorts(ctx)
+				t.Logf("Listed ports: %v", ports)
+
 				assert.NoError(t, err)
-			}()
-			defer cancel()
+				// Ports are returned in arbitrary order
+				assert.Equal(t, len(tt.expectedPorts), len(ports), "invalid number of listed ports")
+				for _, p := range ports {
+					expectedPort := tt.expectedPorts[p.Id]
+					assertProtoEqual(t, expectedPort, p)
+				}
+			})
+		}
+	}
+}
+
+func TestService_RunFullPortsFile(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "ports.json"))
+	require.NoError(t, err)
+	var records map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(content, &records))
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%v workers", workers), func(t *testing.T) {
+			// Given
+			ctx := context.Background()
+			address, client := startServer(t)
+
+			deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+			s, err := ingestsvc.NewService(ingestsvc.Config{
+				PortsFilePath:       filepath.Join("testdata", "ports.json"),
+				PortsServiceAddress: address,
+				Workers:             workers,
+				ErrorPolicy:         ingestsvc.ErrorPolicySkip,
+				DeadLetterFilePath:  deadLetterPath,
+			})
+			require.NoError(t, err)
+
+			// When
+			err = s.Run(ctx)
 
-			client, err := portsclient.NewGRPC(server.Address().String())
+			// Then
+			require.NoError(t, err)
+			ports, err := client.ListPorts(ctx)
 			require.NoError(t, err)
+			assert.Len(t, ports, len(records)-1)
+
+			// Time zone of ARRIC is "America/Argentina", which is not an IANA time zone
+			keys, codes := readDeadLetters(t, deadLetterPath)
+			assert.Equal(t, []string{"ARRIC"}, keys)
+			assert.Equal(t, []string{"InvalidArgument"}, codes)
+
+			// Both CNDAL and CNDLC list the codes of each other, but each code identifies the port with that ID
+			for _, id := range []string{"CNDAL", "CNDLC"} {
+				p, err := client.LookupPort(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, id, p.GetId())
+			}
+		})
+	}
+}
+
+func TestService_RunSync(t *testing.T) {
+	tests := []struct {
+		name           string
+		purge          bool
+		maxDeleteRatio float64
+		expectedError  bool
+		// expectedStaleCode and expectedRestoreCode are the codes of getting and restoring the port absent from the file.
+		expectedStaleCode    codes.Code
+		expectedRestoreCode  codes.Code
+		expectedAjmanVersion int64
+	}{
+		{
+			name:                 "stale port soft-deleted",
+			maxDeleteRatio:       0.5,
+			expectedStaleCode:    codes.NotFound,
+			expectedRestoreCode:  codes.OK,
+			expectedAjmanVersion: 2,
+		}, {
+			name:                 "stale port purged",
+			purge:                true,
+			maxDeleteRatio:       0.5,
+			expectedStaleCode:    codes.NotFound,
+			expectedRestoreCode:  codes.NotFound,
+			expectedAjmanVersion: 2,
+		}, {
+			name:                 "file refused for deleting too many ports",
+			expectedError:        true,
+			expectedStaleCode:    codes.OK,
+			expectedRestoreCode:  codes.NotFound,
+			expectedAjmanVersion: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			ctx := context.Background()
+			address, client := startServer(t)
+			for _, p := range []*portsgrpc.Port{
+				{Id: "AEAJM", Name: "Ajman"},
+				{Id: "AEAUH", Name: "Abu Dhabi"},
+				{Id: "AEDXB", Name: "Dubai"},
+				{Id: "AEFJR", Name: "Al Fujayrah"},
+			} {
+				_, err := client.StorePort(ctx, p)
+				require.NoError(t, err)
+			}
 
 			s, err := ingestsvc.NewService(ingestsvc.Config{
-				PortsFilePath:       tt.filePath,
-				PortsServiceAddress: server.Address().String(),
+				PortsFilePath:       filepath.Join("testdata", "3-ports.json"),
+				PortsServiceAddress: address,
+				Mode:                ingestsvc.ModeSync,
+				SyncPurge:           tt.purge,
+				SyncMaxDeleteRatio:  tt.maxDeleteRatio,
 			})
 			require.NoError(t, err)
 
@@ -99,20 +270,590 @@ func TestService_Run(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			ports, err := client.ListPorts(ctx)
-			t.Logf("Listed ports: %v", ports)
+			ajman, err := client.GetPort(ctx, "AEAJM")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAjmanVersion, ajman.Version)
+
+			_, err = client.GetPort(ctx, "AEFJR")
+			assert.Equal(t, tt.expectedStaleCode, status.Code(err))
+			_, err = client.RestorePort(ctx, "AEFJR")
+			assert.Equal(t, tt.expectedRestoreCode, status.Code(err))
+		})
+	}
+}
+
+func TestService_RunConcurrently(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	address, client := startServer(t)
+
+	// Every third port lacks a name, so the first invalid port is the third one and the last invalid port is the last
+	// one, which exceeds the error limit only after all ports were sent
+	var entries []string
+	for i := 0; i < 30; i++ {
+		name := fmt.Sprintf("Port %v", i)
+		if i%3 == 2 {
+			name = ""
+		}
+		entries = append(entries, fmt.Sprintf(`"PRT%c%c": {"name": %q}`, 'A'+i/26, 'A'+i%26, name))
+	}
+	filePath := filepath.Join(t.TempDir(), "ports.json")
+	require.NoError(t, os.WriteFile(filePath, []byte("{"+strings.Join(entries, ",")+"}"), 0o600))
+
+	s, err := ingestsvc.NewService(ingestsvc.Config{
+		PortsFilePath:       filePath,
+		PortsServiceAddress: address,
+		Workers:             8,
+		ErrorPolicy:         ingestsvc.ErrorPolicyMaxErrors,
+		MaxErrors:           9,
+	})
+	require.NoError(t, err)
+
+	// When
+	err = s.Run(ctx)
+
+	// Then
+	assert.ErrorContains(t, err, "rejected 10 and failed to store 0 ports, first error on port with ID PRTAC")
+	ports, err := client.ListPorts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ports, 20)
+}
+
+func TestService_RunListsErrorsOfAllPorts(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	address, _ := startServer(t)
+
+	// No port has a name, so the stream would have more errors than Ports service lists
+	var entries []string
+	for i := 0; i < portsgrpc.StorePortsMaxErrors+5; i++ {
+		key := fmt.Sprintf("PR%c%c%c", 'A'+i/26/26, 'A'+i/26%26, 'A'+i%26)
+		entries = append(entries, fmt.Sprintf(`"%v": {"unlocs": [%q]}`, key, key))
+	}
+	filePath := filepath.Join(t.TempDir(), "ports.json")
+	require.NoError(t, os.WriteFile(filePath, []byte("{"+strings.Join(entries, ",")+"}"), 0o600))
+
+	deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	s, err := ingestsvc.NewService(ingestsvc.Config{
+		PortsFilePath:       filePath,
+		PortsServiceAddress: address,
+		ErrorPolicy:         ingestsvc.ErrorPolicySkip,
+		DeadLetterFilePath:  deadLetterPath,
+	})
+	require.NoError(t, err)
+
+	// When
+	err = s.Run(ctx)
+
+	// Then
+	assert.NoError(t, err)
+	keys, _ := readDeadLetters(t, deadLetterPath)
+	assert.Len(t, keys, len(entries))
+}
+
+func TestService_RunFailsOnUnlistedErrors(t *testing.T) {
+	// Given
+	server := &fakePortServer{rejectedID: "AEAUH", unlistErrors: true}
+	s, err := ingestsvc.NewService(ingestsvc.Config{
+		PortsFilePath:       filepath.Join("testdata", "3-ports.json"),
+		PortsServiceAddress: startFakePortServer(t, server),
+		ErrorPolicy:         ingestsvc.ErrorPolicySkip,
+	})
+	require.NoError(t, err)
+
+	// When
+	err = s.Run(context.Background())
 
+	// Then
+	assert.ErrorContains(t, err, "ports service did not list errors of 1 ports not stored")
+}
+
+func TestService_RunRetriesUnavailable(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%v workers", workers), func(t *testing.T) {
+			// Given
+			server := &fakePortServer{unavailableCount: 2}
+			s, err := ingestsvc.NewService(ingestsvc.Config{
+				PortsFilePath:       filepath.Join("testdata", "3-ports.json"),
+				PortsServiceAddress: startFakePortServer(t, server),
+				Workers:             workers,
+			})
+			require.NoError(t, err)
+
+			// When
+			err = s.Run(context.Background())
+
+			// Then
 			assert.NoError(t, err)
-			// Ports are returned in arbitrary order
-			assert.Equal(t, len(tt.expectedPorts), len(ports), "invalid number of listed ports")
-			for _, p := range ports {
-				expectedPort := tt.expectedPorts[p.Id]
-				assertProtoEqual(t, expectedPort, p)
+			assert.ElementsMatch(t, []string{"AEAJM", "AEAUH", "AEDXB"}, server.receivedIDs())
+		})
+	}
+}
+
+func TestService_RunRateLimited(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	address, _ := startServer(t)
+
+	s, err := ingestsvc.NewService(ingestsvc.Config{
+		PortsFilePath:       filepath.Join("testdata", "3-ports.json"),
+		PortsServiceAddress: address,
+		Workers:             3,
+		RateLimit:           20,
+	})
+	require.NoError(t, err)
+
+	// When
+	start := time.Now()
+	err = s.Run(ctx)
+
+	// Then
+	assert.NoError(t, err)
+	// The first port is sent immediately, the next ones every 50ms
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestService_RunErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        string
+		maxErrors     int
+		expectedError bool
+		// expectedStoredIDs are checked only if the ingestion is not stopped, because stopping aborts the ports
+		// being sent.
+		expectedStoredIDs []string
+		// expectedDeadLetterCodes are the codes of the dead letters, by record keys in the order of the file lines.
+		expectedDeadLetterKeys  []string
+		expectedDeadLetterCodes []string
+	}{
+		{
+			name:                    "fail-fast stops at record that cannot be decoded",
+			policy:                  ingestsvc.ErrorPolicyFailFast,
+			expectedError:           true,
+			expectedDeadLetterKeys:  []string{"AEAUH", "AEDXB"},
+			expectedDeadLetterCodes: []string{"InvalidArgument", ""},
+		}, {
+			name:                    "skip skips all errors",
+			policy:                  ingestsvc.ErrorPolicySkip,
+			expectedStoredIDs:       []string{"AEAJM", "AEFJR"},
+			expectedDeadLetterKeys:  []string{"AEAUH", "AEDXB"},
+			expectedDeadLetterCodes: []string{"InvalidArgument", ""},
+		}, {
+			name:                    "max-errors fails when errors exceed the limit",
+			policy:                  ingestsvc.ErrorPolicyMaxErrors,
+			maxErrors:               1,
+			expectedError:           true,
+			expectedDeadLetterKeys:  []string{"AEAUH", "AEDXB"},
+			expectedDeadLetterCodes: []string{"InvalidArgument", ""},
+		}, {
+			name:                    "max-errors skips errors up to the limit",
+			policy:                  ingestsvc.ErrorPolicyMaxErrors,
+			maxErrors:               2,
+			expectedStoredIDs:       []string{"AEAJM", "AEFJR"},
+			expectedDeadLetterKeys:  []string{"AEAUH", "AEDXB"},
+			expectedDeadLetterCodes: []string{"InvalidArgument", ""},
+		},
+	}
+	for _, tt := range tests {
+		for _, workers := range []int{1, 4} {
+			t.Run(fmt.Sprintf("%v with %v workers", tt.name, workers), func(t *testing.T) {
+				// Given
+				ctx := context.Background()
+				address, client := startServer(t)
+
+				deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+				s, err := ingestsvc.NewService(ingestsvc.Config{
+					PortsFilePath:       filepath.Join("testdata", "ports-with-errors.json"),
+					PortsServiceAddress: address,
+					Workers:             workers,
+					ErrorPolicy:         tt.policy,
+					MaxErrors:           tt.maxErrors,
+					DeadLetterFilePath:  deadLetterPath,
+				})
+				require.NoError(t, err)
+
+				// When
+				err = s.Run(ctx)
+
+				// Then
+				if tt.expectedError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+
+				if tt.expectedStoredIDs != nil {
+					ports, err := client.ListPorts(ctx)
+					require.NoError(t, err)
+					assert.ElementsMatch(t, tt.expectedStoredIDs, portIDs(ports))
+				}
+
+				keys, codes := readDeadLetters(t, deadLetterPath)
+				assert.Equal(t, tt.expectedDeadLetterKeys, keys)
+				assert.Equal(t, tt.expectedDeadLetterCodes, codes)
+			})
+		}
+	}
+}
+
+func TestService_RunFailFastStopsAtRejectedPort(t *testing.T) {
+	// Given
+	server := &fakePortServer{rejectedID: "AEAUH"}
+	dir := t.TempDir()
+	s, err := ingestsvc.NewService(ingestsvc.Config{
+		PortsFilePath:       filepath.Join("testdata", "3-ports.json"),
+		PortsServiceAddress: startFakePortServer(t, server),
+		ErrorPolicy:         ingestsvc.ErrorPolicyFailFast,
+		DeadLetterFilePath:  filepath.Join(dir, "dead-letter.jsonl"),
+		// Checkpoints due after every port close the stream after every port
+		CheckpointFilePath: filepath.Join(dir, "checkpoint.json"),
+		CheckpointInterval: time.Nanosecond,
+	})
+	require.NoError(t, err)
+
+	// When
+	err = s.Run(context.Background())
+
+	// Then
+	assert.ErrorContains(t, err, "first error on port with ID AEAUH")
+	assert.Equal(t, []string{"AEAJM", "AEAUH"}, server.receivedIDs())
+	keys, _ := readDeadLetters(t, filepath.Join(dir, "dead-letter.jsonl"))
+	assert.Equal(t, []string{"AEAUH"}, keys)
+}
+
+func TestService_RunResume(t *testing.T) {
+	for _, tt := range []struct {
+		workers int
+		// stopAfter is the number of ports received by the server when the ingestion is stopped. The first port
+		// is checkpointed by then: the stream is closed after it, and a worker sends the third port only after it
+		// stored the first one, because the other worker is blocked by the second port.
+		stopAfter int
+	}{
+		{workers: 1, stopAfter: 2},
+		{workers: 2, stopAfter: 3},
+	} {
+		t.Run(fmt.Sprintf("%v workers", tt.workers), func(t *testing.T) {
+			// Given
+			ctx, stop := context.WithCancel(context.Background())
+			defer stop()
+			stoppedServer := &fakePortServer{
+				blockedID: "AEAUH",
+				onReceive: func(count int) {
+					if count == tt.stopAfter {
+						stop()
+					}
+				},
+			}
+			cfg := ingestsvc.Config{
+				PortsFilePath:       filepath.Join("testdata", "3-ports.json"),
+				PortsServiceAddress: startFakePortServer(t, stoppedServer),
+				Workers:             tt.workers,
+				CheckpointFilePath:  filepath.Join(t.TempDir(), "checkpoint.json"),
+				CheckpointInterval:  time.Nanosecond,
+			}
+			s, err := ingestsvc.NewService(cfg)
+			require.NoError(t, err)
+
+			// When
+			err = s.Run(ctx)
+
+			// Then
+			assert.Error(t, err)
+			content, err := os.ReadFile(cfg.CheckpointFilePath)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"records": 1, "key": "AEAJM"}`, checkpointRecordsAndKey(t, content))
+
+			// When
+			resumedServer := &fakePortServer{}
+			cfg.PortsServiceAddress = startFakePortServer(t, resumedServer)
+			cfg.Resume = true
+			s, err = ingestsvc.NewService(cfg)
+			require.NoError(t, err)
+			err = s.Run(context.Background())
+
+			// Then
+			assert.NoError(t, err)
+			assert.NoFileExists(t, cfg.CheckpointFilePath)
+			assert.ElementsMatch(t, []string{"AEAUH", "AEDXB"}, resumedServer.receivedIDs())
+		})
+	}
+}
+
+func TestService_RunResumeKeepsDeadLetters(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		t.Run(fmt.Sprintf("%v workers", workers), func(t *testing.T) {
+			// Given
+			ctx, stop := context.WithCancel(context.Background())
+			defer stop()
+			stoppedServer := &fakePortServer{
+				blockedID:  "AEDXB",
+				rejectedID: "AEAUH",
+				onReceive: func(count int) {
+					if count == 3 {
+						stop()
+					}
+				},
+			}
+			dir := t.TempDir()
+			cfg := ingestsvc.Config{
+				PortsFilePath:       filepath.Join("testdata", "3-ports.json"),
+				PortsServiceAddress: startFakePortServer(t, stoppedServer),
+				Workers:             workers,
+				ErrorPolicy:         ingestsvc.ErrorPolicySkip,
+				DeadLetterFilePath:  filepath.Join(dir, "dead-letter.jsonl"),
+				CheckpointFilePath:  filepath.Join(dir, "checkpoint.json"),
+				CheckpointInterval:  time.Nanosecond,
+			}
+			s, err := ingestsvc.NewService(cfg)
+			require.NoError(t, err)
+			require.Error(t, s.Run(ctx))
+
+			cfg.PortsServiceAddress = startFakePortServer(t, &fakePortServer{rejectedID: "AEAUH"})
+			cfg.Resume = true
+			s, err = ingestsvc.NewService(cfg)
+			require.NoError(t, err)
+
+			// When
+			err = s.Run(context.Background())
+
+			// Then
+			assert.NoError(t, err)
+			keys, codes := readDeadLetters(t, cfg.DeadLetterFilePath)
+			assert.Equal(t, []string{"AEAUH"}, keys)
+			assert.Equal(t, []string{"InvalidArgument"}, codes)
+		})
+	}
+}
+
+func TestService_RunResumeCountsCommittedErrors(t *testing.T) {
+	for _, tt := range []struct {
+		maxErrors     int
+		expectedError bool
+	}{
+		{maxErrors: 0, expectedError: true},
+		{maxErrors: 1, expectedError: false},
+	} {
+		t.Run(fmt.Sprintf("max %v errors", tt.maxErrors), func(t *testing.T) {
+			// Given
+			address, _ := startServer(t)
+			checkpointPath := filepath.Join(t.TempDir(), "checkpoint.json")
+			checkpoint := `{"records": 2, "key": "AEAUH", "errors": 1}`
+			require.NoError(t, os.WriteFile(checkpointPath, []byte(checkpoint), 0o600))
+
+			s, err := ingestsvc.NewService(ingestsvc.Config{
+				PortsFilePath:       filepath.Join("testdata", "3-ports.json"),
+				PortsServiceAddress: address,
+				ErrorPolicy:         ingestsvc.ErrorPolicyMaxErrors,
+				MaxErrors:           tt.maxErrors,
+				CheckpointFilePath:  checkpointPath,
+				Resume:              true,
+			})
+			require.NoError(t, err)
+
+			// When
+			err = s.Run(context.Background())
+
+			// Then
+			if tt.expectedError {
+				assert.ErrorContains(t, err, "1 records were not stored")
+			} else {
+				assert.NoError(t, err)
 			}
 		})
 	}
 }
 
+func TestService_RunResumeChangedFile(t *testing.T) {
+	// Given
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint.json")
+	require.NoError(t, os.WriteFile(checkpointPath, []byte(`{"records": 2, "key": "AEDXB"}`), 0o600))
+
+	s, err := ingestsvc.NewService(ingestsvc.Config{
+		PortsFilePath:      filepath.Join("testdata", "3-ports.json"),
+		CheckpointFilePath: checkpointPath,
+		Resume:             true,
+	})
+	require.NoError(t, err)
+
+	// When
+	err = s.Run(context.Background())
+
+	// Then
+	assert.ErrorContains(t, err, "was the file changed?")
+}
+
+func TestNewService(t *testing.T) {
+	for _, cfg := range []ingestsvc.Config{
+		{Mode: "replace"},
+		{Mode: ingestsvc.ModeSync, SyncMaxDeleteRatio: -0.1},
+		{Mode: ingestsvc.ModeSync, SyncMaxDeleteRatio: 1.1},
+		{Workers: -1},
+		{RateLimit: -1},
+		{ErrorPolicy: "ignore"},
+		{ErrorPolicy: ingestsvc.ErrorPolicyMaxErrors, MaxErrors: -1},
+		{CheckpointInterval: -time.Second},
+		{Resume: true},
+	} {
+		_, err := ingestsvc.NewService(cfg)
+		assert.Error(t, err, "config: %+v", cfg)
+	}
+}
+
+// startServer starts Ports server on a random port and returns its address and a client connected to it.
+// The client is closed and the server is stopped in the test cleanup.
+func startServer(t *testing.T) (string, portsclient.GRPC) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	server, err := portssvc.NewServer(ctx, portssvc.Config{GRPCServerAddress: ":0"})
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		assert.NoError(t, server.Serve(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+
+	address := server.Address().String()
+	client, err := portsclient.NewGRPC(address, portsclient.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, client.Close())
+	})
+	return address, client
+}
+
+// fakePortServer records IDs of the stored ports. Storing the port with blockedID blocks until the call is done,
+// and the port with rejectedID is rejected. The stream summary does not list the errors if unlistErrors is set.
+// The first calls or streams fail with Unavailable code after receiving a port, if unavailableCount is set.
+type fakePortServer struct {
+	portsgrpc.UnimplementedPortServiceServer
+
+	blockedID        string
+	rejectedID       string
+	unlistErrors     bool
+	unavailableCount int
+	// onReceive is called with the number of ports received so far, if set.
+	onReceive func(count int)
+
+	mu       sync.Mutex
+	received []string
+	attempts int
+}
+
+func (s *fakePortServer) StorePort(
+	ctx context.Context, req *portsgrpc.StorePortRequest,
+) (*portsgrpc.StorePortResponse, error) {
+	if err := s.unavailable(); err != nil {
+		return nil, err
+	}
+	return &portsgrpc.StorePortResponse{}, s.store(ctx, req.GetPort())
+}
+
+func (s *fakePortServer) StorePorts(stream portsgrpc.PortService_StorePortsServer) error {
+	summary := &portsgrpc.StorePortsResponse{}
+	var index int64
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+		if index == 0 {
+			if err = s.unavailable(); err != nil {
+				return err
+			}
+		}
+		err = s.store(stream.Context(), req.GetPort())
+		switch status.Code(err) {
+		case codes.OK:
+			summary.StoredCount++
+		case codes.InvalidArgument:
+			summary.RejectedCount++
+			if s.unlistErrors {
+				summary.ErrorsTruncated = true
+				break
+			}
+			summary.Errors = append(summary.Errors, &portsgrpc.StorePortError{
+				Index:   index,
+				PortId:  req.GetPort().GetId(),
+				Code:    int32(codes.InvalidArgument),
+				Message: status.Convert(err).Message(),
+			})
+		default:
+			return err
+		}
+		index++
+	}
+}
+
+func (s *fakePortServer) store(ctx context.Context, p *portsgrpc.Port) error {
+	s.mu.Lock()
+	s.received = append(s.received, p.GetId())
+	count := len(s.received)
+	s.mu.Unlock()
+
+	if s.onReceive != nil {
+		s.onReceive(count)
+	}
+	switch p.GetId() {
+	case s.blockedID:
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	case s.rejectedID:
+		return status.Errorf(codes.InvalidArgument, "port with ID %v rejected", p.GetId())
+	}
+	return nil
+}
+
+// unavailable returns Unavailable error for the first calls or streams.
+func (s *fakePortServer) unavailable() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.attempts <= s.unavailableCount {
+		return status.Error(codes.Unavailable, "server is temporarily unavailable")
+	}
+	return nil
+}
+
+func (s *fakePortServer) receivedIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.received...)
+}
+
+// startFakePortServer starts the server on a random port and returns its address.
+func startFakePortServer(t *testing.T, server *fakePortServer) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer()
+	portsgrpc.RegisterPortServiceServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+	return listener.Addr().String()
+}
+
+// checkpointRecordsAndKey returns the checkpoint file content without the checkpoint time.
+func checkpointRecordsAndKey(t *testing.T, content []byte) string {
+	var checkpoint struct {
+		Records int64  `json:"records"`
+		Key     string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(content, &checkpoint))
+	result, err := json.Marshal(checkpoint)
+	require.NoError(t, err)
+	return string(result)
+}
+
 func assertProtoEqual(t testing.TB, expected, actual proto.Message) {
 	assert.True(
 		t,
@@ -120,3 +861,36 @@ func assertProtoEqual(t testing.TB, expected, actual proto.Message) {
 		fmt.Sprintf("Protobuf messages are not equal:\nexpected: %v\nactual: %v", expected, actual),
 	)
 }
+
+func portIDs(ports []*portsgrpc.Port) []string {
+	ids := make([]string, 0, len(ports))
+	for _, p := range ports {
+		ids = append(ids, p.Id)
+	}
+	return ids
+}
+
+// readDeadLetters returns the keys and codes of the records in the dead letter file. It also checks that the records
+// are the port objects of the keys, as read from the ports file.
+func readDeadLetters(t *testing.T, path string) (keys, codes []string) {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for decoder.More() {
+		var letter struct {
+			Key    string `json:"key"`
+			Record struct {
+				Unlocs []string `json:"unlocs"`
+			} `json:"record"`
+			Code  string `json:"code"`
+			Error string `json:"error"`
+		}
+		require.NoError(t, decoder.Decode(&letter))
+		assert.NotEmpty(t, letter.Error, "dead letter of record with key %v", letter.Key)
+		assert.Equal(t, []string{letter.Key}, letter.Record.Unlocs, "record of dead letter with key %v", letter.Key)
+		keys = append(keys, letter.Key)
+		codes = append(codes, letter.Code)
+	}
+	return keys, codes
+}
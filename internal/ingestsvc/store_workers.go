@@ -0,0 +1,184 @@
+package ingestsvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/portsgrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storeJob is a port to store.
+type storeJob struct {
+	// position is the position of the record in the file, starting from 1.
+	position int64
+	record   portRecord
+}
+
+// storePortsConcurrently decodes the ports and stores them with parallel calls made by the configured number
+// of workers. Decoding stays streaming: it waits while all workers are busy, so only the ports being stored are kept
+// in memory. Sending stops when more ports were not stored than the error policy allows.
+func (s Service) storePortsConcurrently(
+	ctx context.Context, decoder *json.Decoder, errs *ingestErrors, cp *checkpointer,
+) (*portsgrpc.StorePortsResponse, error) {
+	// Cancelling the send context stops sending the jobs if a worker fails or more ports were not stored than
+	// the error policy allows, but lets the calls in progress finish
+	sendCtx, stopSending := context.WithCancel(ctx)
+	defer stopSending()
+
+	var (
+		summary   = &portsgrpc.StorePortsResponse{}
+		summaryMu sync.Mutex
+		workerErr error
+		workers   sync.WaitGroup
+	)
+	fail := func(err error) {
+		summaryMu.Lock()
+		defer summaryMu.Unlock()
+		if workerErr == nil {
+			workerErr = err
+		}
+		stopSending()
+	}
+
+	jobs := make(chan storeJob)
+	for i := 0; i < s.cfg.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				if err := s.storeJob(ctx, job, summary, &summaryMu, errs, cp); err != nil {
+					fail(err)
+				}
+				if errs.exceeded() {
+					stopSending()
+				}
+			}
+		}()
+	}
+
+	err := s.sendJobs(sendCtx, decoder, jobs, errs, cp)
+	close(jobs)
+	// The ports being stored are completed before returning, so that the records before the one that stopped
+	// the decoding are committed with their dead letters
+	stoppedByWorkers := errors.Is(err, context.Canceled) && sendCtx.Err() != nil && ctx.Err() == nil
+	workers.Wait()
+	switch {
+	case err != nil && !stoppedByWorkers:
+		return nil, err
+	case workerErr != nil:
+		return nil, workerErr
+	}
+	// The summary tells which ports were not stored if the workers stopped sending because of the error policy
+	return summary, nil
+}
+
+// storeJob stores the port of the job, adds the result to the summary and completes the record. The record is not
+// completed if the port was not stored because the ingestion is stopping: the error is returned and the port is sent
+// again on resume.
+func (s Service) storeJob(
+	ctx context.Context, job storeJob, summary *portsgrpc.StorePortsResponse, summaryMu *sync.Mutex,
+	errs *ingestErrors, cp *checkpointer,
+) error {
+	sent, outcome, err := s.storePort(ctx, job.record.port)
+
+	summaryMu.Lock()
+	addToSummary(summary, outcome, err)
+	summaryMu.Unlock()
+
+	if err != nil && !(sent && ctx.Err() == nil) {
+		return fmt.Errorf("store port with ID %v in ports service: %w", job.record.key, err)
+	}
+
+	var letter *deadLetter
+	if err != nil {
+		st := status.Convert(err)
+		l := storeDeadLetter(job.record, st.Code(), st.Message())
+		s.addStoreError(l, errs)
+		letter = &l
+	}
+	return cp.complete(job.position, job.record.key, letter)
+}
+
+// sendJobs decodes the ports and sends them to the workers until the end of the file or the context is done.
+// The checkpoint is saved when it is due.
+func (s Service) sendJobs(
+	ctx context.Context, decoder *json.Decoder, jobs chan<- storeJob, errs *ingestErrors, cp *checkpointer,
+) error {
+	position := cp.resumed.Records
+	for decoder.More() {
+		rec, err := s.decodePort(decoder)
+		if err != nil {
+			return err
+		}
+		position++
+
+		if rec.letter != nil {
+			// The record is completed before the ingestion is stopped by the error policy, so that the dead letter
+			// file contains the record that stopped it
+			policyErr := s.addDecodeError(rec, errs)
+			if err = cp.complete(position, rec.key, rec.letter); err != nil {
+				return err
+			}
+			if policyErr != nil {
+				return policyErr
+			}
+		} else {
+			s.log.WithField("port-id", rec.key).Debug("Sending port to ports service")
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case jobs <- storeJob{position: position, record: rec}:
+			}
+		}
+
+		if cp.due() {
+			if err = cp.save(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// storePort stores the port when the rate limiter allows it and returns the outcome. It returns false if the port
+// was not sent, because the context is done or its deadline is too close to wait for the rate limiter.
+func (s Service) storePort(
+	ctx context.Context, port *portsgrpc.Port,
+) (bool, portsgrpc.StorePortResponse_Outcome, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		if ctx.Err() != nil {
+			return false, 0, status.FromContextError(ctx.Err()).Err()
+		}
+		return false, 0, status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	outcome, err := s.portsClient.StorePort(ctx, port)
+	return true, outcome, err
+}
+
+// addToSummary counts the result of storing the port the same way Ports service counts the results of the stream.
+func addToSummary(summary *portsgrpc.StorePortsResponse, outcome portsgrpc.StorePortResponse_Outcome, err error) {
+	if err == nil {
+		summary.StoredCount++
+		switch outcome {
+		case portsgrpc.StorePortResponse_CREATED:
+			summary.CreatedCount++
+		case portsgrpc.StorePortResponse_UPDATED:
+			summary.UpdatedCount++
+		case portsgrpc.StorePortResponse_UNCHANGED:
+			summary.UnchangedCount++
+		}
+		return
+	}
+
+	code := status.Code(err)
+	if code == codes.InvalidArgument || code == codes.AlreadyExists {
+		summary.RejectedCount++
+	} else {
+		summary.FailedCount++
+	}
+}
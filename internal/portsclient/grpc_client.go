@@ -3,62 +3,396 @@ package portsclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/danielfurman/ports-microservices/internal/logs"
 	"github.com/danielfurman/ports-microservices/internal/portssvc/portsgrpc"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
+// WithCaller returns a copy of the context that makes the requests of the client identify the caller, so that
+// the changes they make are attributed to it in the port history. The caller is not authenticated.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, portsgrpc.CallerMetadataKey, caller)
+}
+
 // GRPC is Ports service gRPC client.
 type GRPC struct {
-	client     portsgrpc.PortServiceClient
-	connection *grpc.ClientConn
-	log        *logrus.Entry
+	client      portsgrpc.PortServiceClient
+	connection  *grpc.ClientConn
+	callTimeout time.Duration
+	log         *logrus.Entry
 }
 
 // NewGRPC creates new Ports service gRPC client for given server address.
 // GRPC.Close() should be called when client is no longer needed.
-func NewGRPC(serverAddress string) (GRPC, error) {
+func NewGRPC(serverAddress string, opts Options) (GRPC, error) {
 	log := logs.NewLogger("ports-client")
 
-	log.WithField("server-address", serverAddress).Debug("Dialing gRPC")
-	connection, err := grpc.Dial(serverAddress, dialOptions()...)
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return GRPC{}, fmt.Errorf("invalid client options: %w", err)
+	}
+	dialOpts, err := dialOptions(opts)
+	if err != nil {
+		return GRPC{}, err
+	}
+
+	log.WithFields(logrus.Fields{
+		"server-address": serverAddress,
+		"options":        fmt.Sprintf("%+v", opts),
+	}).Debug("Dialing gRPC")
+	connection, err := grpc.Dial(serverAddress, dialOpts...)
 	if err != nil {
 		return GRPC{}, fmt.Errorf("gRPC dial on %v: %w", serverAddress, err)
 	}
 
 	return GRPC{
-		client:     portsgrpc.NewPortServiceClient(connection),
-		connection: connection,
-		log:        log,
+		client:      portsgrpc.NewPortServiceClient(connection),
+		connection:  connection,
+		callTimeout: opts.CallTimeout,
+		log:         log,
 	}, nil
 }
 
-func dialOptions() []grpc.DialOption {
-	// TODO(dfurman): support TLS, timeout, logging, retries
+// dialOptions returns the options of the connection. Deadlines, retries and waiting for ready server are configured
+// with the default service config, which is used unless the name resolver provides other one.
+func dialOptions(opts Options) ([]grpc.DialOption, error) {
+	// TODO(dfurman): support TLS, logging
+	serviceConfig, err := opts.serviceConfig()
+	if err != nil {
+		return nil, err
+	}
 	return []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+	}, nil
+}
+
+// StorePort stores given port in Ports service and returns the outcome. The stored port with the same content
+// is not changed and the outcome is UNCHANGED.
+func (g GRPC) StorePort(ctx context.Context, port *portsgrpc.Port) (portsgrpc.StorePortResponse_Outcome, error) {
+	resp, err := g.client.StorePort(ctx, &portsgrpc.StorePortRequest{
+		Port: port,
+	})
+	return resp.GetOutcome(), err
+}
+
+// openStream calls open with a context that is cancelled if the stream is not opened within the call timeout,
+// e.g. when the client waits for Ports service that is down. The returned function cancels the opened stream.
+func (g GRPC) openStream(ctx context.Context, open func(ctx context.Context) error) (context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(g.callTimeout, cancel)
+	err := open(ctx)
+	if !timer.Stop() {
+		cancel()
+		return nil, status.Errorf(codes.DeadlineExceeded, "stream not opened within %v", g.callTimeout)
 	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return cancel, nil
 }
 
-// StorePort stores given port in Ports service.
-func (g GRPC) StorePort(ctx context.Context, port *portsgrpc.Port) error {
-	_, err := g.client.StorePort(ctx, &portsgrpc.StorePortRequest{
+// StorePorts opens a stream that stores all sent ports in Ports service with a single call.
+// Cancel the context to abort the stream.
+func (g GRPC) StorePorts(ctx context.Context) (*StorePortsStream, error) {
+	var stream portsgrpc.PortService_StorePortsClient
+	cancel, err := g.openStream(ctx, func(ctx context.Context) (err error) {
+		stream, err = g.client.StorePorts(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &StorePortsStream{stream: stream, cancel: cancel}, nil
+}
+
+// StorePortsStream sends ports to be stored in Ports service.
+type StorePortsStream struct {
+	stream portsgrpc.PortService_StorePortsClient
+	cancel context.CancelFunc
+}
+
+// Send sends the port to be stored. Invalid ports do not fail the stream - they are reported in the summary
+// returned by CloseAndRecv.
+func (s *StorePortsStream) Send(port *portsgrpc.Port) error {
+	err := s.stream.Send(&portsgrpc.StorePortRequest{
 		Port: port,
 	})
+	if errors.Is(err, io.EOF) {
+		// The stream was aborted by the server - the actual error is returned on receive
+		_, err = s.stream.CloseAndRecv()
+	}
+	if err != nil {
+		s.cancel()
+	}
 	return err
 }
 
-// ListPorts lists all ports stored in Ports service.
+// CloseAndRecv closes the stream and returns the summary of stored ports.
+func (s *StorePortsStream) CloseAndRecv() (*portsgrpc.StorePortsResponse, error) {
+	defer s.cancel()
+	return s.stream.CloseAndRecv()
+}
+
+// GetPort gets the port with given ID from Ports service.
+// The error with gRPC NotFound code is returned if there is no such port.
+func (g GRPC) GetPort(ctx context.Context, id string) (*portsgrpc.Port, error) {
+	return g.client.GetPort(ctx, &portsgrpc.GetPortRequest{
+		Id: id,
+	})
+}
+
+// LookupPort returns the port identified by given UN/LOCODE, which is either its ID or any of its unlocs.
+func (g GRPC) LookupPort(ctx context.Context, unloc string) (*portsgrpc.Port, error) {
+	return g.client.LookupPort(ctx, &portsgrpc.LookupPortRequest{
+		Unloc: unloc,
+	})
+}
+
+// UpdatePort updates fields of the port listed in paths, e.g. "timezone", to the values of given port and returns
+// the updated port. The port to update is identified by the ID of given port.
+func (g GRPC) UpdatePort(ctx context.Context, port *portsgrpc.Port, paths ...string) (*portsgrpc.Port, error) {
+	return g.client.UpdatePort(ctx, &portsgrpc.UpdatePortRequest{
+		Port:       port,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+	})
+}
+
+// FindNearbyPorts finds ports nearest to the location, ordered by distance. Ports farther than radiusKm are not
+// returned, unless it is zero. The server default limit is used if the limit is zero.
+func (g GRPC) FindNearbyPorts(
+	ctx context.Context, location *portsgrpc.LatLng, radiusKm float64, limit int32,
+) ([]*portsgrpc.NearbyPort, error) {
+	resp, err := g.client.FindNearbyPorts(ctx, &portsgrpc.FindNearbyPortsRequest{
+		Location: location,
+		RadiusKm: radiusKm,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPorts(), nil
+}
+
+// DeletePort deletes the port with given ID from Ports service. Soft-deleted port can be restored with RestorePort,
+// purged port is removed permanently.
+func (g GRPC) DeletePort(ctx context.Context, id string, purge bool) error {
+	_, err := g.client.DeletePort(ctx, &portsgrpc.DeletePortRequest{
+		Id:    id,
+		Purge: purge,
+	})
+	return err
+}
+
+// RestorePort restores the soft-deleted port with given ID and returns it.
+func (g GRPC) RestorePort(ctx context.Context, id string) (*portsgrpc.Port, error) {
+	return g.client.RestorePort(ctx, &portsgrpc.RestorePortRequest{
+		Id: id,
+	})
+}
+
+// ListPorts lists all ports stored in Ports service. Ports are retrieved page by page and ordered by ID.
 func (g GRPC) ListPorts(ctx context.Context) ([]*portsgrpc.Port, error) {
-	response, err := g.client.ListPorts(ctx, &emptypb.Empty{})
+	var (
+		result    []*portsgrpc.Port
+		pageToken string
+	)
+	for {
+		ps, nextPageToken, err := g.ListPortsPage(ctx, 0, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ps...)
+
+		if nextPageToken == "" {
+			return result, nil
+		}
+		pageToken = nextPageToken
+	}
+}
+
+// ListPortsPage lists a page of ports stored in Ports service. Server default page size is used if pageSize is zero.
+// Empty pageToken points to the first page. Returned next page token is empty if there are no more pages.
+func (g GRPC) ListPortsPage(ctx context.Context, pageSize int32, pageToken string) ([]*portsgrpc.Port, string, error) {
+	response, err := g.client.ListPorts(ctx, &portsgrpc.ListPortsRequest{
+		PageSize:  pageSize,
+		PageToken: pageToken,
+	})
+	return response.GetPorts(), response.GetNextPageToken(), err
+}
+
+// SearchPortsPage lists a page of ports matching the filters of the request, ordered by ID.
+// Returned next page token is empty if there are no more pages.
+func (g GRPC) SearchPortsPage(
+	ctx context.Context, req *portsgrpc.SearchPortsRequest,
+) ([]*portsgrpc.Port, string, error) {
+	response, err := g.client.SearchPorts(ctx, req)
+	return response.GetPorts(), response.GetNextPageToken(), err
+}
+
+// SuggestPorts returns ports matching the text typed by a user, ordered from the best matching ones.
+// Server default limit is used if limit is zero.
+func (g GRPC) SuggestPorts(ctx context.Context, query string, limit int32) ([]*portsgrpc.ScoredPort, error) {
+	response, err := g.client.SuggestPorts(ctx, &portsgrpc.SuggestPortsRequest{
+		Query: query,
+		Limit: limit,
+	})
 	return response.GetPorts(), err
 }
 
+// GetPortHistory returns the audit history of the port with given ID, ordered from the oldest change. Entries are
+// retrieved page by page.
+func (g GRPC) GetPortHistory(ctx context.Context, id string) ([]*portsgrpc.PortHistoryEntry, error) {
+	var (
+		result    []*portsgrpc.PortHistoryEntry
+		pageToken string
+	)
+	for {
+		response, err := g.client.GetPortHistory(ctx, &portsgrpc.GetPortHistoryRequest{
+			Id:        id,
+			PageToken: pageToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, response.GetEntries()...)
+
+		if response.GetNextPageToken() == "" {
+			return result, nil
+		}
+		pageToken = response.GetNextPageToken()
+	}
+}
+
+// StreamPorts streams all ports stored in Ports service, ordered by ID.
+// Cancel the context to stop streaming before all ports are received.
+func (g GRPC) StreamPorts(ctx context.Context) (*PortIterator, error) {
+	var stream portsgrpc.PortService_StreamPortsClient
+	cancel, err := g.openStream(ctx, func(ctx context.Context) (err error) {
+		stream, err = g.client.StreamPorts(ctx, &portsgrpc.StreamPortsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PortIterator{stream: stream, cancel: cancel}, nil
+}
+
+// PortIterator iterates over streamed ports:
+//
+//	for it.Next() {
+//		port := it.Port()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PortIterator struct {
+	stream portsgrpc.PortService_StreamPortsClient
+	cancel context.CancelFunc
+	port   *portsgrpc.Port
+	err    error
+}
+
+// Next receives the next port. It returns false when the stream ends or fails.
+func (it *PortIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	p, err := it.stream.Recv()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			it.err = err
+		}
+		it.port = nil
+		it.cancel()
+		return false
+	}
+
+	it.port = p
+	return true
+}
+
+// Port returns the port received by the last Next call.
+func (it *PortIterator) Port() *portsgrpc.Port {
+	return it.port
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *PortIterator) Err() error {
+	return it.err
+}
+
+// WatchPorts streams events of port changes after given revision, or after the current one if the revision is zero.
+// Changes made after WatchPorts returns are streamed. To resume watching after reconnecting, pass the revision
+// of the last received event. Cancel the context to stop watching.
+func (g GRPC) WatchPorts(ctx context.Context, afterRevision int64) (*PortEventIterator, error) {
+	var stream portsgrpc.PortService_WatchPortsClient
+	cancel, err := g.openStream(ctx, func(ctx context.Context) (err error) {
+		stream, err = g.client.WatchPorts(ctx, &portsgrpc.WatchPortsRequest{AfterRevision: afterRevision})
+		if err != nil {
+			return err
+		}
+		// The server sends the header once it started watching
+		_, err = stream.Header()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PortEventIterator{stream: stream, cancel: cancel}, nil
+}
+
+// PortEventIterator iterates over streamed port events, like PortIterator iterates over ports.
+type PortEventIterator struct {
+	stream portsgrpc.PortService_WatchPortsClient
+	cancel context.CancelFunc
+	event  *portsgrpc.PortEvent
+	err    error
+}
+
+// Next receives the next event. It returns false when the stream ends or fails.
+func (it *PortEventIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	e, err := it.stream.Recv()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			it.err = err
+		}
+		it.event = nil
+		it.cancel()
+		return false
+	}
+
+	it.event = e
+	return true
+}
+
+// Event returns the event received by the last Next call.
+func (it *PortEventIterator) Event() *portsgrpc.PortEvent {
+	return it.event
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *PortEventIterator) Err() error {
+	return it.err
+}
+
 // Close closes the client connection.
 func (g GRPC) Close() error {
 	return g.connection.Close()
@@ -0,0 +1,260 @@
+package portsclient_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielfurman/ports-microservices/internal/portsclient"
+	"github.com/danielfurman/ports-microservices/internal/portssvc/portsgrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestGRPC_Retries(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             portsclient.Options
+		call             func(ctx context.Context, client portsclient.GRPC) error
+		expectedCode     codes.Code
+		expectedAttempts int
+	}{
+		{
+			name: "idempotent call retried",
+			opts: portsclient.Options{InitialBackoff: time.Millisecond},
+			call: func(ctx context.Context, client portsclient.GRPC) error {
+				_, err := client.GetPort(ctx, "AEAJM")
+				return err
+			},
+			expectedCode:     codes.OK,
+			expectedAttempts: 3,
+		}, {
+			name: "idempotent call failed after max attempts",
+			opts: portsclient.Options{InitialBackoff: time.Millisecond, MaxAttempts: 2},
+			call: func(ctx context.Context, client portsclient.GRPC) error {
+				_, err := client.GetPort(ctx, "AEAJM")
+				return err
+			},
+			expectedCode:     codes.Unavailable,
+			expectedAttempts: 2,
+		}, {
+			name: "store retried",
+			opts: portsclient.Options{InitialBackoff: time.Millisecond},
+			call: func(ctx context.Context, client portsclient.GRPC) error {
+				_, err := client.StorePort(ctx, &portsgrpc.Port{Id: "AEAJM"})
+				return err
+			},
+			expectedCode:     codes.OK,
+			expectedAttempts: 3,
+		}, {
+			name: "non-idempotent call not retried",
+			opts: portsclient.Options{InitialBackoff: time.Millisecond},
+			call: func(ctx context.Context, client portsclient.GRPC) error {
+				return client.DeletePort(ctx, "AEAJM", false)
+			},
+			expectedCode:     codes.Unavailable,
+			expectedAttempts: 1,
+		}, {
+			name: "retries disabled",
+			opts: portsclient.Options{MaxAttempts: 1},
+			call: func(ctx context.Context, client portsclient.GRPC) error {
+				_, err := client.GetPort(ctx, "AEAJM")
+				return err
+			},
+			expectedCode:     codes.Unavailable,
+			expectedAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			server := &fakePortServer{unavailableCount: 2}
+			address := startFakePortServer(t, server, "127.0.0.1:0")
+			client, err := portsclient.NewGRPC(address, tt.opts)
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, client.Close())
+			}()
+
+			// When
+			err = tt.call(context.Background(), client)
+
+			// Then
+			assert.Equal(t, tt.expectedCode, status.Code(err), "error: %v", err)
+			assert.Equal(t, tt.expectedAttempts, server.attemptCount())
+		})
+	}
+}
+
+func TestGRPC_CallTimeout(t *testing.T) {
+	// Given
+	server := &fakePortServer{blocking: true}
+	address := startFakePortServer(t, server, "127.0.0.1:0")
+	client, err := portsclient.NewGRPC(address, portsclient.Options{CallTimeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, client.Close())
+	}()
+
+	// When
+	start := time.Now()
+	_, err = client.GetPort(context.Background(), "AEAJM")
+
+	// Then
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err), "error: %v", err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestGRPC_WaitForReady(t *testing.T) {
+	for _, tt := range []struct {
+		waitForReady bool
+		expectedCode codes.Code
+	}{
+		{waitForReady: true, expectedCode: codes.OK},
+		{waitForReady: false, expectedCode: codes.Unavailable},
+	} {
+		t.Run(fmt.Sprintf("wait for ready %v", tt.waitForReady), func(t *testing.T) {
+			// Given
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			address := listener.Addr().String()
+			require.NoError(t, listener.Close())
+
+			client, err := portsclient.NewGRPC(address, portsclient.Options{
+				MaxAttempts:  1,
+				WaitForReady: tt.waitForReady,
+			})
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, client.Close())
+			}()
+
+			// The server is started after the call
+			serverStarted := make(chan struct{})
+			go func() {
+				defer close(serverStarted)
+				time.Sleep(200 * time.Millisecond)
+				startFakePortServer(t, &fakePortServer{}, address)
+			}()
+			defer func() {
+				<-serverStarted
+			}()
+
+			// When
+			_, err = client.GetPort(context.Background(), "AEAJM")
+
+			// Then
+			assert.Equal(t, tt.expectedCode, status.Code(err), "error: %v", err)
+		})
+	}
+}
+
+func TestGRPC_StreamOpenTimeout(t *testing.T) {
+	// Given
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	client, err := portsclient.NewGRPC(address, portsclient.Options{
+		CallTimeout:  50 * time.Millisecond,
+		WaitForReady: true,
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, client.Close())
+	}()
+
+	// When
+	start := time.Now()
+	_, err = client.StorePorts(context.Background())
+
+	// Then
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err), "error: %v", err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewGRPC(t *testing.T) {
+	for _, opts := range []portsclient.Options{
+		{CallTimeout: -time.Second},
+		{MaxAttempts: -1},
+		{MaxAttempts: 6},
+		{InitialBackoff: -time.Second},
+		{BackoffMultiplier: -1},
+	} {
+		_, err := portsclient.NewGRPC("localhost:9090", opts)
+		assert.Error(t, err, "options: %+v", opts)
+	}
+}
+
+// fakePortServer fails the first calls with Unavailable code and then succeeds, or blocks until the call is done.
+type fakePortServer struct {
+	portsgrpc.UnimplementedPortServiceServer
+
+	// unavailableCount is a number of the first calls failed with Unavailable code.
+	unavailableCount int
+	// blocking makes the server block until the call is done.
+	blocking bool
+
+	mu       sync.Mutex
+	attempts int
+}
+
+func (s *fakePortServer) StorePort(
+	ctx context.Context, _ *portsgrpc.StorePortRequest,
+) (*portsgrpc.StorePortResponse, error) {
+	return &portsgrpc.StorePortResponse{}, s.handle(ctx)
+}
+
+func (s *fakePortServer) GetPort(ctx context.Context, req *portsgrpc.GetPortRequest) (*portsgrpc.Port, error) {
+	return &portsgrpc.Port{Id: req.GetId()}, s.handle(ctx)
+}
+
+func (s *fakePortServer) DeletePort(ctx context.Context, _ *portsgrpc.DeletePortRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, s.handle(ctx)
+}
+
+func (s *fakePortServer) handle(ctx context.Context) error {
+	s.mu.Lock()
+	s.attempts++
+	attempt := s.attempts
+	s.mu.Unlock()
+
+	if s.blocking {
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if attempt <= s.unavailableCount {
+		return status.Error(codes.Unavailable, "server is temporarily unavailable")
+	}
+	return nil
+}
+
+func (s *fakePortServer) attemptCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+// startFakePortServer starts the server on given address and returns the address it listens on.
+func startFakePortServer(t *testing.T, server *fakePortServer, address string) string {
+	listener, err := net.Listen("tcp", address)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+
+	grpcServer := grpc.NewServer()
+	portsgrpc.RegisterPortServiceServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+	return listener.Addr().String()
+}
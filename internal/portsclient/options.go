@@ -0,0 +1,157 @@
+package portsclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/portsgrpc"
+)
+
+// Default Options values used when the options are not set.
+const (
+	DefaultCallTimeout       = 10 * time.Second
+	DefaultMaxAttempts       = 4
+	DefaultInitialBackoff    = 100 * time.Millisecond
+	DefaultMaxBackoff        = 2 * time.Second
+	DefaultBackoffMultiplier = 2
+)
+
+// maxAttemptsLimit is a maximum number of attempts supported by gRPC retries.
+const maxAttemptsLimit = 5
+
+// Options are options of the Ports service gRPC client. Zero values are replaced by the defaults.
+type Options struct {
+	// CallTimeout is a deadline of unary calls, including all their attempts, and of opening the streams.
+	// Opened streams have no deadline, so that they can be long-lived - use the context to limit them.
+	CallTimeout time.Duration
+	// MaxAttempts is a maximum number of attempts of queries and stores that failed with Unavailable code,
+	// including the first attempt. It can be at most 5. Set it to 1 to disable retries.
+	MaxAttempts int
+	// InitialBackoff, MaxBackoff and BackoffMultiplier configure exponential backoff between the attempts.
+	// The actual backoff is randomized between zero and the exponential one.
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+	// WaitForReady makes the calls wait until the server is ready, instead of failing when it cannot be connected.
+	WaitForReady bool
+}
+
+// withDefaults returns the options with defaults set and validates them.
+func (o Options) withDefaults() (Options, error) {
+	if o.CallTimeout == 0 {
+		o.CallTimeout = DefaultCallTimeout
+	}
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = DefaultMaxAttempts
+	}
+	if o.InitialBackoff == 0 {
+		o.InitialBackoff = DefaultInitialBackoff
+	}
+	if o.MaxBackoff == 0 {
+		o.MaxBackoff = DefaultMaxBackoff
+	}
+	if o.BackoffMultiplier == 0 {
+		o.BackoffMultiplier = DefaultBackoffMultiplier
+	}
+
+	switch {
+	case o.CallTimeout < 0:
+		return Options{}, fmt.Errorf("call timeout %v must be positive", o.CallTimeout)
+	case o.MaxAttempts < 0 || o.MaxAttempts > maxAttemptsLimit:
+		return Options{}, fmt.Errorf("max attempts %v is not in range [1, %v]", o.MaxAttempts, maxAttemptsLimit)
+	case o.InitialBackoff < 0 || o.MaxBackoff < 0:
+		return Options{}, fmt.Errorf("backoffs %v and %v must be positive", o.InitialBackoff, o.MaxBackoff)
+	case o.BackoffMultiplier < 0:
+		return Options{}, fmt.Errorf("backoff multiplier %v must be positive", o.BackoffMultiplier)
+	}
+	return o, nil
+}
+
+// retriedMethods are the methods that can be retried: the queries, and storing a port, which stores the same port
+// on every attempt. The streams are retried only until the first response is received, so storing ports over
+// a stream is not retried - the caller can send them again over a new stream.
+var retriedMethods = []string{
+	"ListPorts", "SearchPorts", "SuggestPorts", "GetPort", "LookupPort", "FindNearbyPorts",
+	"GetPortHistory", "StreamPorts", "WatchPorts", "StorePort",
+}
+
+// serviceConfig models gRPC service config, see https://github.com/grpc/grpc/blob/master/doc/service_config.md.
+type serviceConfig struct {
+	MethodConfig []methodConfig `json:"methodConfig"`
+}
+
+type methodConfig struct {
+	Name         []methodName `json:"name"`
+	WaitForReady bool         `json:"waitForReady"`
+	Timeout      string       `json:"timeout,omitempty"`
+	RetryPolicy  *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// serviceConfig returns gRPC service config that applies the options to the methods of Ports service.
+func (o Options) serviceConfig() (string, error) {
+	var retry *retryPolicy
+	if o.MaxAttempts > 1 {
+		retry = &retryPolicy{
+			MaxAttempts:          o.MaxAttempts,
+			InitialBackoff:       formatDuration(o.InitialBackoff),
+			MaxBackoff:           formatDuration(o.MaxBackoff),
+			BackoffMultiplier:    o.BackoffMultiplier,
+			RetryableStatusCodes: []string{"UNAVAILABLE"},
+		}
+	}
+
+	retried := make(map[string]bool, len(retriedMethods))
+	for _, m := range retriedMethods {
+		retried[m] = true
+	}
+
+	service := portsgrpc.PortService_ServiceDesc.ServiceName
+	var cfg serviceConfig
+	for _, m := range portsgrpc.PortService_ServiceDesc.Methods {
+		mc := methodConfig{
+			Name:         []methodName{{Service: service, Method: m.MethodName}},
+			WaitForReady: o.WaitForReady,
+			Timeout:      formatDuration(o.CallTimeout),
+		}
+		if retried[m.MethodName] {
+			mc.RetryPolicy = retry
+		}
+		cfg.MethodConfig = append(cfg.MethodConfig, mc)
+	}
+	for _, s := range portsgrpc.PortService_ServiceDesc.Streams {
+		mc := methodConfig{
+			Name:         []methodName{{Service: service, Method: s.StreamName}},
+			WaitForReady: o.WaitForReady,
+		}
+		if retried[s.StreamName] {
+			mc.RetryPolicy = retry
+		}
+		cfg.MethodConfig = append(cfg.MethodConfig, mc)
+	}
+
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("encode service config: %w", err)
+	}
+	return string(content), nil
+}
+
+// formatDuration formats the duration as JSON representation of protobuf Duration, e.g. "0.1s".
+func formatDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
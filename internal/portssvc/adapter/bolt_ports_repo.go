@@ -0,0 +1,207 @@
+package adapter
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/danielfurman/ports-microservices/internal/logs"
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltFileName    = "ports.db"
+	boltOpenTimeout = time.Second
+)
+
+var (
+	// boltPortsBucket is a name of the bucket that maps port IDs to JSON-encoded port records.
+	boltPortsBucket = []byte("ports")
+	// boltChangesBucket is a name of the outbox bucket that maps big-endian event sequences to JSON-encoded port
+	// change records. Its sequence is the sequence of the latest event.
+	boltChangesBucket = []byte("port_changes")
+	// boltHistoryBucket is a name of the bucket with a nested bucket per port ID, which maps big-endian sequences
+	// of the port history entries to JSON-encoded history records.
+	boltHistoryBucket = []byte("port_history")
+)
+
+// BoltPortsRepository allows to store Ports in a file with embedded bbolt key-value database.
+// It is meant for single-node deployments: the file can be opened by only one process at a time.
+//
+// The repository serves reads from InMemoryPortsRepository that it fills with all stored ports on creation.
+// Every change is written to the file before it is applied in memory. It is safe for concurrent use.
+type BoltPortsRepository struct {
+	*InMemoryPortsRepository
+	db *bolt.DB
+}
+
+// NewBoltPortsRepository opens the database file in given data directory and loads stored ports to memory.
+// The directory and the file are created if they do not exist.
+// BoltPortsRepository.Close() should be called when repository is no longer needed.
+func NewBoltPortsRepository(dataDir string) (*BoltPortsRepository, error) {
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+
+	path := filepath.Join(dataDir, boltFileName)
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open bolt DB %v: %w", path, err)
+	}
+
+	r := &BoltPortsRepository{
+		InMemoryPortsRepository: NewInMemoryPortsRepository(),
+		db:                      db,
+	}
+	r.log = logs.NewLogger("bolt-ports-repo")
+	r.journal = boltJournal{db: db}
+
+	if err = r.load(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("load ports from bolt DB %v: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *BoltPortsRepository) load() error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(boltPortsBucket)
+		if err != nil {
+			return fmt.Errorf("create ports bucket: %w", err)
+		}
+
+		err = b.ForEach(func(k, v []byte) error {
+			var rec portRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("decode port with ID %s: %w", k, err)
+			}
+			r.putPort(rec.toDomain(), rec.Deleted)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		changes, err := tx.CreateBucketIfNotExists(boltChangesBucket)
+		if err != nil {
+			return fmt.Errorf("create port changes bucket: %w", err)
+		}
+		err = changes.ForEach(func(k, v []byte) error {
+			var rec portChangeRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("decode port change %v: %w", binary.BigEndian.Uint64(k), err)
+			}
+			return r.addChangeRecord(&rec)
+		})
+		if err != nil {
+			return err
+		}
+		r.lastChangeSequence = int64(changes.Sequence())
+
+		history, err := tx.CreateBucketIfNotExists(boltHistoryBucket)
+		if err != nil {
+			return fmt.Errorf("create port history bucket: %w", err)
+		}
+		return history.ForEach(func(id, _ []byte) error {
+			return history.Bucket(id).ForEach(func(k, v []byte) error {
+				var rec historyRecord
+				if err := json.Unmarshal(v, &rec); err != nil {
+					return fmt.Errorf("decode history entry %v of port with ID %s: %w",
+						binary.BigEndian.Uint64(k), id, err)
+				}
+				return r.addHistoryRecords(string(id), []historyRecord{rec})
+			})
+		})
+	})
+}
+
+// Close closes the database file.
+func (r *BoltPortsRepository) Close() error {
+	return r.db.Close()
+}
+
+type boltJournal struct {
+	db *bolt.DB
+}
+
+func (j boltJournal) putPort(p *ports.Port, deleted bool, c portChange) error {
+	value, err := json.Marshal(newPortRecord(p, deleted))
+	if err != nil {
+		return fmt.Errorf("encode port: %w", err)
+	}
+
+	return j.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltPortsBucket).Put([]byte(p.ID), value); err != nil {
+			return err
+		}
+		if err := putBoltHistoryEntry(tx, p.ID, c.entry); err != nil {
+			return err
+		}
+		return putBoltChange(tx, c.event)
+	})
+}
+
+func (j boltJournal) removePort(id string, c portChange) error {
+	return j.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltPortsBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		if err := putBoltHistoryEntry(tx, id, c.entry); err != nil {
+			return err
+		}
+		return putBoltChange(tx, c.event)
+	})
+}
+
+func (j boltJournal) ackChanges(sequences []int64) error {
+	return j.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltChangesBucket)
+		for _, seq := range sequences {
+			if err := b.Delete(boltChangeKey(seq)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// putBoltChange puts the event to the outbox bucket. It is a no-op if the event is nil.
+func putBoltChange(tx *bolt.Tx, change *ports.PortChanged) error {
+	if change == nil {
+		return nil
+	}
+	value, err := json.Marshal(newPortChangeRecord(*change))
+	if err != nil {
+		return fmt.Errorf("encode port change: %w", err)
+	}
+
+	b := tx.Bucket(boltChangesBucket)
+	if err = b.Put(boltChangeKey(change.Sequence), value); err != nil {
+		return err
+	}
+	return b.SetSequence(uint64(change.Sequence))
+}
+
+// putBoltHistoryEntry puts the entry to the history bucket of the port.
+func putBoltHistoryEntry(tx *bolt.Tx, id string, e ports.HistoryEntry) error {
+	value, err := json.Marshal(newHistoryRecord(e))
+	if err != nil {
+		return fmt.Errorf("encode history entry: %w", err)
+	}
+
+	b, err := tx.Bucket(boltHistoryBucket).CreateBucketIfNotExists([]byte(id))
+	if err != nil {
+		return err
+	}
+	return b.Put(boltChangeKey(e.Sequence), value)
+}
+
+func boltChangeKey(seq int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(seq))
+	return key
+}
@@ -0,0 +1,177 @@
+package adapter
+
+import (
+	"math"
+	"sort"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+)
+
+// geoCellDegrees is the size of geoIndex cells in degrees of latitude and longitude.
+const geoCellDegrees = 1
+
+// geoCell identifies the cell of the latitude-longitude grid.
+type geoCell struct {
+	latIndex int
+	lngIndex int
+}
+
+func geoCellOf(p ports.LatLng) geoCell {
+	c := geoCell{
+		latIndex: int(math.Floor((p.Lat + 90) / geoCellDegrees)),
+		lngIndex: int(math.Floor((p.Lng + 180) / geoCellDegrees)),
+	}
+	// The north pole and the antimeridian belong to the last cells
+	if maxLat := 180/geoCellDegrees - 1; c.latIndex > maxLat {
+		c.latIndex = maxLat
+	}
+	if maxLng := 360/geoCellDegrees - 1; c.lngIndex > maxLng {
+		c.lngIndex = maxLng
+	}
+	return c
+}
+
+// minDistanceKm returns the lower bound of the distance between the point and any point in the cell.
+func (c geoCell) minDistanceKm(p ports.LatLng) float64 {
+	minLat := float64(c.latIndex)*geoCellDegrees - 90
+	maxLat := minLat + geoCellDegrees
+	minLng := float64(c.lngIndex)*geoCellDegrees - 180
+	maxLng := minLng + geoCellDegrees
+
+	if lngDelta(p.Lng, minLng)+lngDelta(p.Lng, maxLng) <= geoCellDegrees+1e-9 {
+		// The point is within the cell longitudes, so the nearest cell point is on the same meridian
+		lat := math.Max(minLat, math.Min(p.Lat, maxLat))
+		return ports.DistanceKm(p, ports.LatLng{Lat: lat, Lng: p.Lng})
+	}
+
+	// Otherwise, the nearest cell point is on the nearer meridian edge of the cell
+	edgeLng := minLng
+	if lngDelta(p.Lng, maxLng) < lngDelta(p.Lng, minLng) {
+		edgeLng = maxLng
+	}
+
+	// The distance along the edge is minimal at its ends or at the point nearest to the whole meridian
+	result := math.Min(
+		ports.DistanceKm(p, ports.LatLng{Lat: minLat, Lng: edgeLng}),
+		ports.DistanceKm(p, ports.LatLng{Lat: maxLat, Lng: edgeLng}),
+	)
+	if cosDLng := math.Cos(radians(edgeLng - p.Lng)); cosDLng > 0 {
+		nearestLat := math.Atan(math.Tan(radians(p.Lat))/cosDLng) * 180 / math.Pi
+		if nearestLat > minLat && nearestLat < maxLat {
+			result = math.Min(result, ports.DistanceKm(p, ports.LatLng{Lat: nearestLat, Lng: edgeLng}))
+		}
+	}
+	return result
+}
+
+// lngDelta returns the absolute difference of the longitudes in range [0, 180].
+func lngDelta(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// geoIndex is a spatial index of port locations. Ports are bucketed into the cells of the latitude-longitude
+// grid, so that nearest ports queries skip the cells that are farther than the ports already found.
+// It is not safe for concurrent use.
+type geoIndex struct {
+	cells     map[geoCell]map[string]ports.LatLng
+	locations map[string]ports.LatLng
+}
+
+func newGeoIndex() *geoIndex {
+	return &geoIndex{
+		cells:     make(map[geoCell]map[string]ports.LatLng),
+		locations: make(map[string]ports.LatLng),
+	}
+}
+
+// put indexes the port location. The port is removed from the index if the location is nil.
+func (x *geoIndex) put(id string, location *ports.LatLng) {
+	x.remove(id)
+	if location == nil {
+		return
+	}
+
+	cell := geoCellOf(*location)
+	if x.cells[cell] == nil {
+		x.cells[cell] = make(map[string]ports.LatLng)
+	}
+	x.cells[cell][id] = *location
+	x.locations[id] = *location
+}
+
+func (x *geoIndex) remove(id string) {
+	location, ok := x.locations[id]
+	if !ok {
+		return
+	}
+
+	cell := geoCellOf(location)
+	delete(x.cells[cell], id)
+	if len(x.cells[cell]) == 0 {
+		delete(x.cells, cell)
+	}
+	delete(x.locations, id)
+}
+
+// geoIndexHit is a port found in geoIndex.
+type geoIndexHit struct {
+	id         string
+	distanceKm float64
+}
+
+// nearest returns IDs of the ports nearest to the query location, ordered by distance and ID.
+func (x *geoIndex) nearest(q ports.NearbyQuery) []geoIndexHit {
+	type cellDistance struct {
+		cell          geoCell
+		minDistanceKm float64
+	}
+	cells := make([]cellDistance, 0, len(x.cells))
+	for cell := range x.cells {
+		d := cell.minDistanceKm(*q.Location)
+		if q.RadiusKm > 0 && d > q.RadiusKm {
+			continue
+		}
+		cells = append(cells, cellDistance{cell: cell, minDistanceKm: d})
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].minDistanceKm < cells[j].minDistanceKm
+	})
+
+	var hits []geoIndexHit
+	for _, c := range cells {
+		// Ports in the remaining cells cannot be nearer than the ports already found
+		if len(hits) >= q.Limit && c.minDistanceKm > hits[q.Limit-1].distanceKm {
+			break
+		}
+
+		for id, location := range x.cells[c.cell] {
+			d := ports.DistanceKm(*q.Location, location)
+			if q.RadiusKm > 0 && d > q.RadiusKm {
+				continue
+			}
+			hits = append(hits, geoIndexHit{id: id, distanceKm: d})
+		}
+		sortGeoIndexHits(hits)
+		if len(hits) > q.Limit {
+			hits = hits[:q.Limit]
+		}
+	}
+	return hits
+}
+
+func sortGeoIndexHits(hits []geoIndexHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].distanceKm != hits[j].distanceKm {
+			return hits[i].distanceKm < hits[j].distanceKm
+		}
+		return hits[i].id < hits[j].id
+	})
+}
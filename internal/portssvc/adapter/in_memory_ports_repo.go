@@ -2,8 +2,11 @@ package adapter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/danielfurman/ports-microservices/internal/logs"
 	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
@@ -13,46 +16,820 @@ import (
 // InMemoryPortsRepository allows to store Ports in memory with a hashmap data structure.
 // It is safe for concurrent use.
 type InMemoryPortsRepository struct {
-	ports      map[string]*ports.Port
+	ports map[string]*ports.Port
+	// sortedIDs contains IDs of all stored ports. It is kept in ascending order unless sortedIDsStale is set.
+	sortedIDs      []string
+	sortedIDsStale bool
+	// deletedIDs contains IDs of soft-deleted ports. They are kept in ports map, but hidden from reads.
+	deletedIDs map[string]struct{}
+	// geoIndex indexes locations of ports that are not soft-deleted.
+	geoIndex *geoIndex
+	// unlocIndex indexes Unlocs of ports that are not soft-deleted.
+	unlocIndex *unlocIndex
+	// searchIndex indexes fields of ports that are not soft-deleted. Its keys are sorted together with sortedIDs.
+	searchIndex *searchIndex
+	// outbox contains pending PortChanged events in sequence order, if outboxEnabled is set.
+	outbox        []ports.PortChanged
+	outboxEnabled bool
+	// lastChangeSequence is the sequence of the latest PortChanged event.
+	lastChangeSequence int64
+	// history contains history entries of ports by port ID, including purged ports.
+	history map[string][]ports.HistoryEntry
+	// journal persists changes before they are applied in memory. It is nil if the repository is not persistent.
+	journal    portsJournal
 	portsMutex sync.RWMutex
 	log        *logrus.Entry
+
+	// wal is the journal of the repository created with NewInMemoryPortsRepositoryWithWAL, nil otherwise.
+	wal            *writeAheadLog
+	snapshotMutex  sync.Mutex
+	stopSnapshots  chan struct{}
+	snapshotsEnded chan struct{}
+}
+
+// WALOptions configures the write-ahead log of InMemoryPortsRepository.
+type WALOptions struct {
+	// Dir is a directory of the write-ahead log segments and snapshots. It is created if it does not exist.
+	Dir string
+	// SnapshotInterval is an interval of compacting the write-ahead log into a snapshot.
+	// Periodic snapshots are disabled if it is zero.
+	SnapshotInterval time.Duration
+	// Sync enables syncing the write-ahead log to disk after every change. Without it, changes survive the service
+	// crash, but can be lost on the operating system crash.
+	Sync bool
+}
+
+// portsJournal durably records changes of InMemoryPortsRepository, so that they survive the service restart.
+// Its methods are called with the repository locked for writing.
+type portsJournal interface {
+	// putPort records that the port was stored with given soft-deletion state, together with the records of the change.
+	putPort(p *ports.Port, deleted bool, c portChange) error
+	// removePort records that the port was permanently removed, together with the records of the change.
+	removePort(id string, c portChange) error
+	// ackChanges records that the events with given sequences were removed from the outbox.
+	ackChanges(sequences []int64) error
+}
+
+// portChange contains the records of the port change kept besides the port state.
+type portChange struct {
+	// event is the outbox event of the change. It is nil if the outbox is not enabled.
+	event *ports.PortChanged
+	// entry is the history entry of the change.
+	entry ports.HistoryEntry
 }
 
 // NewInMemoryPortsRepository creates a new repository.
 func NewInMemoryPortsRepository() *InMemoryPortsRepository {
 	return &InMemoryPortsRepository{
-		ports: make(map[string]*ports.Port),
-		log:   logs.NewLogger("in-memory-ports-repo"),
+		ports:       make(map[string]*ports.Port),
+		deletedIDs:  make(map[string]struct{}),
+		geoIndex:    newGeoIndex(),
+		unlocIndex:  newUnlocIndex(),
+		searchIndex: newSearchIndex(),
+		history:     make(map[string][]ports.HistoryEntry),
+		log:         logs.NewLogger("in-memory-ports-repo"),
 	}
 }
 
-// StorePort stores given port in memory.
-func (r *InMemoryPortsRepository) StorePort(_ context.Context, port *ports.Port) error {
-	r.log.WithField("port", port).Debug("Storing port")
+// NewInMemoryPortsRepositoryWithWAL creates a repository that records every change in an append-only write-ahead
+// log on disk, and periodically compacts the log into a snapshot. The repository state is rebuilt from the latest
+// snapshot and the log tail. InMemoryPortsRepository.Close() should be called when repository is no longer needed.
+func NewInMemoryPortsRepositoryWithWAL(opts WALOptions) (*InMemoryPortsRepository, error) {
+	r := NewInMemoryPortsRepository()
+
+	start := time.Now()
+	wal, err := openWAL(opts.Dir, opts.Sync, r.log, r.applyWALEntry)
+	if err != nil {
+		return nil, fmt.Errorf("open WAL in %v: %w", opts.Dir, err)
+	}
+	r.log.WithFields(logrus.Fields{
+		"ports-count": len(r.ports),
+		"duration":    time.Since(start),
+	}).Info("Recovered ports from WAL")
+
+	r.wal = wal
+	r.journal = wal
+	r.stopSnapshots = make(chan struct{})
+	r.snapshotsEnded = make(chan struct{})
+	go r.snapshotPeriodically(opts.SnapshotInterval)
+	return r, nil
+}
+
+// EnableOutbox makes the repository record PortChanged events of the port changes.
+// It should be called before the repository is used.
+func (r *InMemoryPortsRepository) EnableOutbox() {
 	r.portsMutex.Lock()
 	defer r.portsMutex.Unlock()
+	r.outboxEnabled = true
+}
 
-	r.ports[port.ID] = port
+func (r *InMemoryPortsRepository) applyWALEntry(e walEntry) error {
+	switch e.Op {
+	case walOpPut:
+		if e.Port == nil {
+			return errors.New("WAL put entry without port")
+		}
+		r.putPort(e.Port.toDomain(), e.Port.Deleted)
+		if err := r.addHistoryRecords(e.Port.ID, e.History); err != nil {
+			return err
+		}
+		return r.addChangeRecord(e.Change)
+	case walOpRemove:
+		r.removePort(e.ID)
+		if err := r.addHistoryRecords(e.ID, e.History); err != nil {
+			return err
+		}
+		return r.addChangeRecord(e.Change)
+	case walOpAck:
+		r.removeChanges(e.Sequences)
+		return nil
+	case walOpOutbox:
+		if e.Outbox == nil {
+			return errors.New("WAL outbox entry without outbox")
+		}
+		r.outbox = nil
+		for _, rec := range e.Outbox.Pending {
+			if err := r.addChangeRecord(&rec); err != nil {
+				return err
+			}
+		}
+		r.lastChangeSequence = e.Outbox.LastSequence
+		return nil
+	case walOpHistory:
+		delete(r.history, e.ID)
+		return r.addHistoryRecords(e.ID, e.History)
+	default:
+		return fmt.Errorf("unknown WAL entry operation %q", e.Op)
+	}
+}
+
+func (r *InMemoryPortsRepository) snapshotPeriodically(interval time.Duration) {
+	defer close(r.snapshotsEnded)
+	if interval <= 0 {
+		<-r.stopSnapshots
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopSnapshots:
+			return
+		case <-ticker.C:
+			if err := r.Snapshot(); err != nil {
+				r.log.WithError(err).Error("Failed to snapshot ports")
+			}
+		}
+	}
+}
+
+// Snapshot writes all stored ports to a snapshot file and removes the write-ahead log segments covered by it.
+// It is a no-op if the repository has no write-ahead log or nothing changed since the last snapshot.
+func (r *InMemoryPortsRepository) Snapshot() error {
+	if r.wal == nil {
+		return nil
+	}
+	r.snapshotMutex.Lock()
+	defer r.snapshotMutex.Unlock()
+
+	r.portsMutex.Lock()
+	if !r.wal.hasChanges() {
+		r.portsMutex.Unlock()
+		return nil
+	}
+
+	seq, err := r.wal.rotate()
+	// Stored ports are never modified in place, so records can share their slices
+	records := make([]portRecord, 0, len(r.ports))
+	for id, p := range r.ports {
+		_, deleted := r.deletedIDs[id]
+		records = append(records, newPortRecord(p, deleted))
+	}
+	history := make(map[string][]historyRecord, len(r.history))
+	for id, entries := range r.history {
+		for _, e := range entries {
+			history[id] = append(history[id], newHistoryRecord(e))
+		}
+	}
+	outbox := outboxRecord{LastSequence: r.lastChangeSequence}
+	for _, e := range r.outbox {
+		outbox.Pending = append(outbox.Pending, newPortChangeRecord(e))
+	}
+	r.portsMutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("rotate WAL: %w", err)
+	}
+
+	r.log.WithFields(logrus.Fields{
+		"ports-count":   len(records),
+		"pending-count": len(outbox.Pending),
+		"wal-seq":       seq,
+	}).Debug("Writing ports snapshot")
+	return r.wal.writeSnapshot(seq, snapshotState{
+		ports:   records,
+		history: history,
+		outbox:  outbox,
+	})
+}
+
+// Close stops periodic snapshots and closes the write-ahead log.
+// It is a no-op if the repository has no write-ahead log.
+func (r *InMemoryPortsRepository) Close() error {
+	if r.wal == nil {
+		return nil
+	}
+	close(r.stopSnapshots)
+	<-r.snapshotsEnded
+
+	r.portsMutex.Lock()
+	defer r.portsMutex.Unlock()
+	return r.wal.close()
+}
+
+// StorePort stores given port in memory with the next version and returns the outcome. The stored port with the same
+// content is not changed.
+func (r *InMemoryPortsRepository) StorePort(
+	ctx context.Context, port *ports.Port,
+) (ports.Port, ports.StoreOutcome, error) {
+	r.portsMutex.Lock()
+	defer r.portsMutex.Unlock()
+
+	var storedVersion int64
+	p, err := r.livePort(port.ID)
+	created := err != nil
+	if !created {
+		storedVersion = p.Version
+	}
+	if port.Version != 0 && port.Version != storedVersion {
+		return ports.Port{}, 0, fmt.Errorf("store port: %w", ports.NewConflictError(port.ID, port.Version))
+	}
+	if !created && p.ContentHash() == port.ContentHash() {
+		return *copyPort(p), ports.StoreUnchanged, nil
+	}
+	r.log.WithField("port", port).Debug("Storing port")
+	if err = r.unlocIndex.checkUnique(port); err != nil {
+		return ports.Port{}, 0, fmt.Errorf("store port: %w", err)
+	}
+
+	// Given port is copied with its slices and location, so that it is not modified by the caller after storing
+	stored := copyPort(port)
+	stored.Version = r.nextVersion(port.ID)
+	eventType, outcome := ports.EventUpdated, ports.StoreUpdated
+	if created {
+		eventType, outcome = ports.EventCreated, ports.StoreCreated
+	}
+	change := r.newChange(ctx, eventType, r.ports[port.ID], stored)
+
+	if r.journal != nil {
+		if err = r.journal.putPort(stored, false, change); err != nil {
+			return ports.Port{}, 0, fmt.Errorf("journal port with ID %v: %w", port.ID, err)
+		}
+	}
+
+	r.putPort(stored, false)
+	r.addPortChange(port.ID, change)
+	return *copyPort(stored), outcome, nil
+}
+
+// newChange returns the records of the port change from the previous port to the new one, which is nil if the port
+// is deleted. The history entry gets the next sequence of the port history, and the outbox event gets the next
+// sequence of the outbox, if it is enabled. The caller must hold the write lock.
+func (r *InMemoryPortsRepository) newChange(
+	ctx context.Context, t ports.EventType, previous, port *ports.Port,
+) portChange {
+	changed := port
+	if changed == nil {
+		changed = previous
+	}
+
+	c := portChange{entry: ports.NewHistoryEntry(ctx, t, previous, port)}
+	c.entry.Sequence = 1
+	if h := r.history[changed.ID]; len(h) > 0 {
+		c.entry.Sequence = h[len(h)-1].Sequence + 1
+	}
+	if r.outboxEnabled {
+		c.event = &ports.PortChanged{
+			Sequence: r.lastChangeSequence + 1,
+			Type:     t,
+			Port:     *changed,
+			Time:     c.entry.Time,
+		}
+	}
+	return c
+}
+
+// addPortChange adds the records of the journaled change of the port with given ID to its history and the outbox.
+// The caller must hold the write lock.
+func (r *InMemoryPortsRepository) addPortChange(id string, c portChange) {
+	r.history[id] = append(r.history[id], c.entry)
+	r.addChange(c.event)
+}
+
+func (r *InMemoryPortsRepository) addHistoryRecords(id string, records []historyRecord) error {
+	for _, rec := range records {
+		entry, err := rec.toDomain()
+		if err != nil {
+			return fmt.Errorf("decode history entry %v of port with ID %v: %w", rec.Sequence, id, err)
+		}
+		r.history[id] = append(r.history[id], entry)
+	}
+	return nil
+}
+
+// addChange adds the journaled event to the outbox. It is a no-op if the event is nil.
+// The caller must hold the write lock.
+func (r *InMemoryPortsRepository) addChange(e *ports.PortChanged) {
+	if e == nil {
+		return
+	}
+	r.outbox = append(r.outbox, *e)
+	r.lastChangeSequence = e.Sequence
+}
+
+func (r *InMemoryPortsRepository) addChangeRecord(rec *portChangeRecord) error {
+	if rec == nil {
+		return nil
+	}
+	e, err := rec.toDomain()
+	if err != nil {
+		return fmt.Errorf("decode port change %v: %w", rec.Sequence, err)
+	}
+	r.addChange(&e)
 	return nil
 }
 
-// ListPorts lists all ports stored in memory.
-func (r *InMemoryPortsRepository) ListPorts(_ context.Context) ([]ports.Port, error) {
-	r.log.Debug("Listing ports")
+// removeChanges removes the events with given sequences from the outbox. The caller must hold the write lock.
+func (r *InMemoryPortsRepository) removeChanges(sequences []int64) {
+	removed := make(map[int64]struct{}, len(sequences))
+	for _, seq := range sequences {
+		removed[seq] = struct{}{}
+	}
+
+	pending := r.outbox[:0]
+	for _, e := range r.outbox {
+		if _, ok := removed[e.Sequence]; !ok {
+			pending = append(pending, e)
+		}
+	}
+	// Removed events are cleared, so that their ports can be garbage collected
+	for i := len(pending); i < len(r.outbox); i++ {
+		r.outbox[i] = ports.PortChanged{}
+	}
+	r.outbox = pending
+}
+
+// nextVersion returns the version of the next change of the port. Versions of soft-deleted ports are continued.
+// The caller must hold the lock.
+func (r *InMemoryPortsRepository) nextVersion(id string) int64 {
+	if p, ok := r.ports[id]; ok {
+		return p.Version + 1
+	}
+	return 1
+}
+
+// putPort puts the port in memory. The caller must hold the write lock.
+func (r *InMemoryPortsRepository) putPort(port *ports.Port, deleted bool) {
+	if _, ok := r.ports[port.ID]; !ok {
+		r.addSortedID(port.ID)
+	}
+	r.ports[port.ID] = port
+
+	if deleted {
+		r.deletedIDs[port.ID] = struct{}{}
+		r.geoIndex.remove(port.ID)
+		r.unlocIndex.remove(port.ID)
+		r.searchIndex.remove(port.ID)
+	} else {
+		delete(r.deletedIDs, port.ID)
+		r.geoIndex.put(port.ID, port.Location)
+		r.unlocIndex.put(port)
+		r.searchIndex.put(port)
+	}
+}
+
+// removePort removes the port from memory. The caller must hold the write lock.
+func (r *InMemoryPortsRepository) removePort(id string) {
+	if _, ok := r.ports[id]; !ok {
+		return
+	}
+	delete(r.ports, id)
+	delete(r.deletedIDs, id)
+	r.geoIndex.remove(id)
+	r.unlocIndex.remove(id)
+	r.searchIndex.remove(id)
+
+	for i, sortedID := range r.sortedIDs {
+		if sortedID == id {
+			r.sortedIDs = append(r.sortedIDs[:i], r.sortedIDs[i+1:]...)
+			return
+		}
+	}
+}
+
+// addSortedID appends the ID to sortedIDs. Sorting is deferred to the next listing if the order gets broken,
+// so that storing ports in random order does not need to move the IDs on every insert.
+func (r *InMemoryPortsRepository) addSortedID(id string) {
+	if n := len(r.sortedIDs); n > 0 && r.sortedIDs[n-1] > id {
+		r.sortedIDsStale = true
+	}
+	r.sortedIDs = append(r.sortedIDs, id)
+}
+
+// GetPort returns the port with given ID or ports.NotFoundError.
+func (r *InMemoryPortsRepository) GetPort(_ context.Context, id string) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Getting port")
 	r.portsMutex.RLock()
 	defer r.portsMutex.RUnlock()
 
-	ps, err := portsToSlice(r.ports)
-	return ps, err
+	p, err := r.livePort(id)
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("get port: %w", err)
+	}
+	return *p, nil
+}
+
+// LookupPort returns the port identified by given code or ports.NotFoundError.
+func (r *InMemoryPortsRepository) LookupPort(_ context.Context, unloc string) (ports.Port, error) {
+	r.log.WithField("unloc", unloc).Debug("Looking up port")
+	r.portsMutex.RLock()
+	defer r.portsMutex.RUnlock()
+
+	if p, err := r.livePort(unloc); err == nil {
+		return *p, nil
+	}
+	id, ok := r.unlocIndex.lookup(unloc)
+	if !ok {
+		return ports.Port{}, fmt.Errorf("look up port: %w", ports.NewNotFoundError(unloc))
+	}
+	p, err := r.livePort(id)
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("look up port: %w", err)
+	}
+	return *p, nil
+}
+
+// livePort returns the port that is not soft-deleted. The caller must hold the lock.
+func (r *InMemoryPortsRepository) livePort(id string) (*ports.Port, error) {
+	p, ok := r.ports[id]
+	if !ok || p == nil {
+		return nil, ports.NewNotFoundError(id)
+	}
+	if _, deleted := r.deletedIDs[id]; deleted {
+		return nil, ports.NewNotFoundError(id)
+	}
+	return p, nil
+}
+
+// ListPorts lists ports stored in memory in ascending ID order, starting after the cursor position.
+func (r *InMemoryPortsRepository) ListPorts(_ context.Context, c ports.Cursor) ([]ports.Port, error) {
+	r.log.WithField("cursor", c).Debug("Listing ports")
+	r.readLockSorted()
+	defer r.portsMutex.RUnlock()
+
+	return r.listPorts(ports.PortFilter{}, c)
+}
+
+// listPorts lists ports matching the filter by scanning sortedIDs. The caller must hold the lock with sorted IDs.
+func (r *InMemoryPortsRepository) listPorts(f ports.PortFilter, c ports.Cursor) ([]ports.Port, error) {
+	start := sort.SearchStrings(r.sortedIDs, c.AfterID)
+	if start < len(r.sortedIDs) && r.sortedIDs[start] == c.AfterID {
+		start++
+	}
+
+	result := make([]ports.Port, 0, c.Limit)
+	for _, id := range r.sortedIDs[start:] {
+		if c.Limit > 0 && len(result) == c.Limit {
+			break
+		}
+		if _, deleted := r.deletedIDs[id]; deleted {
+			continue
+		}
+
+		p := r.ports[id]
+		if p == nil {
+			return nil, fmt.Errorf("nil port in repository on key %v", id)
+		}
+		if f.Matches(*p) {
+			result = append(result, *p)
+		}
+	}
+	return result, nil
+}
+
+// SearchPorts lists ports matching the filter in ascending ID order, starting after the cursor position.
+// Ports are looked up in the search index, unless the filter is empty.
+func (r *InMemoryPortsRepository) SearchPorts(
+	_ context.Context, f ports.PortFilter, c ports.Cursor,
+) ([]ports.Port, error) {
+	r.log.WithFields(logrus.Fields{
+		"filter": f,
+		"cursor": c,
+	}).Debug("Searching ports")
+	r.readLockSorted()
+	defer r.portsMutex.RUnlock()
+
+	candidates, ok := r.searchIndex.candidates(f)
+	if !ok {
+		return r.listPorts(f, c)
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for id := range candidates {
+		if id > c.AfterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	result := make([]ports.Port, 0, c.Limit)
+	for _, id := range ids {
+		if c.Limit > 0 && len(result) == c.Limit {
+			break
+		}
+
+		p := r.ports[id]
+		if p == nil {
+			return nil, fmt.Errorf("nil port in repository on key %v", id)
+		}
+		if f.Matches(*p) {
+			result = append(result, *p)
+		}
+	}
+	return result, nil
 }
 
-func portsToSlice(portsM map[string]*ports.Port) ([]ports.Port, error) {
-	result := make([]ports.Port, 0, len(portsM))
-	for k, p := range portsM {
+// SuggestPorts finds ports matching the text query. Ports with terms matching the query terms are found in
+// the search index, and then scored by the query matcher.
+func (r *InMemoryPortsRepository) SuggestPorts(_ context.Context, q ports.TextQuery) ([]ports.ScoredPort, error) {
+	r.log.WithField("query", q).Debug("Suggesting ports")
+	r.readLockSorted()
+	defer r.portsMutex.RUnlock()
+
+	m := q.Matcher()
+	var result []ports.ScoredPort
+	for id := range r.searchIndex.textCandidates(m) {
+		p := r.ports[id]
 		if p == nil {
-			return nil, fmt.Errorf("nil port in repository on key %v", k)
+			return nil, fmt.Errorf("nil port in repository on key %v", id)
+		}
+		if score, ok := m.Score(*p); ok {
+			result = append(result, ports.ScoredPort{
+				Port:  *p,
+				Score: score,
+			})
+		}
+	}
+
+	ports.SortScoredPorts(result)
+	if len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result, nil
+}
+
+// FindNearbyPorts finds ports nearest to the query location using the spatial index.
+func (r *InMemoryPortsRepository) FindNearbyPorts(_ context.Context, q ports.NearbyQuery) ([]ports.NearbyPort, error) {
+	r.log.WithField("query", q).Debug("Finding nearby ports")
+	r.portsMutex.RLock()
+	defer r.portsMutex.RUnlock()
+
+	hits := r.geoIndex.nearest(q)
+	result := make([]ports.NearbyPort, 0, len(hits))
+	for _, hit := range hits {
+		p := r.ports[hit.id]
+		if p == nil {
+			return nil, fmt.Errorf("nil port in repository on key %v", hit.id)
+		}
+		result = append(result, ports.NearbyPort{
+			Port:       *p,
+			DistanceKm: hit.distanceKm,
+		})
+	}
+	return result, nil
+}
+
+// readLockSorted acquires the read lock with sortedIDs and search index names in ascending order.
+func (r *InMemoryPortsRepository) readLockSorted() {
+	for {
+		r.portsMutex.RLock()
+		if !r.sortedIDsStale && !r.searchIndex.isStale() {
+			return
+		}
+		r.portsMutex.RUnlock()
+
+		r.portsMutex.Lock()
+		if r.sortedIDsStale {
+			sort.Strings(r.sortedIDs)
+			r.sortedIDsStale = false
+		}
+		if r.searchIndex.isStale() {
+			r.searchIndex.sort()
+		}
+		r.portsMutex.Unlock()
+	}
+}
+
+// UpdatePort applies the update function to a copy of the port with given ID and stores the result.
+func (r *InMemoryPortsRepository) UpdatePort(
+	ctx context.Context, id string, update func(*ports.Port) error,
+) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Updating port")
+	r.portsMutex.Lock()
+	defer r.portsMutex.Unlock()
+
+	p, err := r.livePort(id)
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("update port: %w", err)
+	}
+
+	// Stored ports are never modified in place, so the update is applied to a copy
+	updated := copyPort(p)
+	if err = update(updated); err != nil {
+		return ports.Port{}, err
+	}
+	updated.ID = id
+	updated.Version = p.Version + 1
+	if err = r.unlocIndex.checkUnique(updated); err != nil {
+		return ports.Port{}, fmt.Errorf("update port: %w", err)
+	}
+
+	change := r.newChange(ctx, ports.EventUpdated, p, updated)
+	if r.journal != nil {
+		if err = r.journal.putPort(updated, false, change); err != nil {
+			return ports.Port{}, fmt.Errorf("journal port with ID %v: %w", id, err)
+		}
+	}
+
+	r.putPort(updated, false)
+	r.addPortChange(id, change)
+	return *updated, nil
+}
+
+// DeletePort soft-deletes the port with given ID and returns it with the next version, or returns
+// ports.NotFoundError.
+func (r *InMemoryPortsRepository) DeletePort(ctx context.Context, id string) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Deleting port")
+	r.portsMutex.Lock()
+	defer r.portsMutex.Unlock()
+
+	p, err := r.livePort(id)
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("delete port: %w", err)
+	}
+
+	deleted := *p
+	deleted.Version = p.Version + 1
+	change := r.newChange(ctx, ports.EventDeleted, p, nil)
+	if change.event != nil {
+		// The event carries the deleted port, while the history entry keeps the port before the deletion
+		change.event.Port = deleted
+	}
+	if r.journal != nil {
+		if err = r.journal.putPort(&deleted, true, change); err != nil {
+			return ports.Port{}, fmt.Errorf("journal port with ID %v: %w", id, err)
+		}
+	}
+
+	r.putPort(&deleted, true)
+	r.addPortChange(id, change)
+	return deleted, nil
+}
+
+// RestorePort restores the soft-deleted port with given ID and returns it with the next version, or returns
+// ports.NotFoundError.
+func (r *InMemoryPortsRepository) RestorePort(ctx context.Context, id string) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Restoring port")
+	r.portsMutex.Lock()
+	defer r.portsMutex.Unlock()
+
+	p, ok := r.ports[id]
+	_, deleted := r.deletedIDs[id]
+	if !ok || !deleted {
+		return ports.Port{}, fmt.Errorf("restore deleted port: %w", ports.NewNotFoundError(id))
+	}
+	if err := r.unlocIndex.checkUnique(p); err != nil {
+		return ports.Port{}, fmt.Errorf("restore deleted port: %w", err)
+	}
+
+	restored := *p
+	restored.Version = p.Version + 1
+	change := r.newChange(ctx, ports.EventCreated, p, &restored)
+	if r.journal != nil {
+		if err := r.journal.putPort(&restored, false, change); err != nil {
+			return ports.Port{}, fmt.Errorf("journal port with ID %v: %w", id, err)
+		}
+	}
+
+	r.putPort(&restored, false)
+	r.addPortChange(id, change)
+	return restored, nil
+}
+
+// PurgePort permanently removes the port with given ID and returns it, or returns ports.NotFoundError.
+func (r *InMemoryPortsRepository) PurgePort(ctx context.Context, id string) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Purging port")
+	r.portsMutex.Lock()
+	defer r.portsMutex.Unlock()
+
+	p, ok := r.ports[id]
+	if !ok {
+		return ports.Port{}, fmt.Errorf("purge port: %w", ports.NewNotFoundError(id))
+	}
+
+	change := r.newChange(ctx, ports.EventDeleted, p, nil)
+	if r.journal != nil {
+		if err := r.journal.removePort(id, change); err != nil {
+			return ports.Port{}, fmt.Errorf("journal removal of port with ID %v: %w", id, err)
 		}
-		result = append(result, *p)
+	}
+
+	r.removePort(id)
+	r.addPortChange(id, change)
+	return *p, nil
+}
+
+// GetPortHistory lists history entries of the port with given ID, starting after the cursor sequence.
+func (r *InMemoryPortsRepository) GetPortHistory(
+	_ context.Context, id string, c ports.HistoryCursor,
+) ([]ports.HistoryEntry, error) {
+	r.log.WithFields(logrus.Fields{
+		"port-id": id,
+		"cursor":  c,
+	}).Debug("Getting port history")
+	r.portsMutex.RLock()
+	defer r.portsMutex.RUnlock()
+
+	history, ok := r.history[id]
+	if !ok {
+		return nil, fmt.Errorf("get port history: %w", ports.NewNotFoundError(id))
+	}
+
+	start := sort.Search(len(history), func(i int) bool {
+		return history[i].Sequence > c.AfterSequence
+	})
+	end := len(history)
+	if c.Limit > 0 && end-start > c.Limit {
+		end = start + c.Limit
+	}
+	result := make([]ports.HistoryEntry, end-start)
+	copy(result, history[start:end])
+	// Stored ports are never modified in place, so the caller gets copies of the entry ports
+	for i := range result {
+		result[i].Previous = copyPort(result[i].Previous)
+		result[i].Port = copyPort(result[i].Port)
 	}
 	return result, nil
 }
+
+// copyPort returns a deep copy of the port, which shares no slices or location with it.
+func copyPort(p *ports.Port) *ports.Port {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	c.Alias = copyStrings(p.Alias)
+	c.Regions = copyStrings(p.Regions)
+	c.Unlocs = copyStrings(p.Unlocs)
+	if p.Location != nil {
+		location := *p.Location
+		c.Location = &location
+	}
+	return &c
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(s)), s...)
+}
+
+// PendingPortChanges returns up to limit oldest events of the outbox.
+func (r *InMemoryPortsRepository) PendingPortChanges(_ context.Context, limit int) ([]ports.PortChanged, error) {
+	r.portsMutex.RLock()
+	defer r.portsMutex.RUnlock()
+
+	if limit > len(r.outbox) {
+		limit = len(r.outbox)
+	}
+	result := make([]ports.PortChanged, limit)
+	copy(result, r.outbox)
+	return result, nil
+}
+
+// AckPortChanges removes the events with given sequences from the outbox.
+func (r *InMemoryPortsRepository) AckPortChanges(_ context.Context, sequences []int64) error {
+	r.log.WithField("count", len(sequences)).Debug("Acknowledging port changes")
+	r.portsMutex.Lock()
+	defer r.portsMutex.Unlock()
+
+	if r.journal != nil {
+		if err := r.journal.ackChanges(sequences); err != nil {
+			return fmt.Errorf("journal acknowledged port changes: %w", err)
+		}
+	}
+
+	r.removeChanges(sequences)
+	return nil
+}
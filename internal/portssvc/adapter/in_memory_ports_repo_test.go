@@ -0,0 +1,270 @@
+package adapter_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/adapter"
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryPortsRepository_StorePortCopiesPort(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repo := adapter.NewInMemoryPortsRepository()
+	p := newPortWithID("AEAJM")
+	storePort(t, repo, p)
+	expected := newPortWithID("AEAJM")
+	expected.Version = 1
+
+	// When
+	p.Alias[0] = "changed"
+	p.Regions = append(p.Regions[:0], "changed")
+	p.Unlocs[0] = "CHANG"
+	p.Location.Lat = 0
+
+	// Then
+	stored, err := repo.GetPort(ctx, "AEAJM")
+	require.NoError(t, err)
+	assert.Equal(t, *expected, stored)
+}
+
+func TestInMemoryPortsRepository_FindNearbyPorts(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repo := adapter.NewInMemoryPortsRepository()
+	random := rand.New(rand.NewSource(1))
+
+	var stored []ports.Port
+	for i := 0; i < 500; i++ {
+		p := newPortWithID(fmt.Sprintf("P%04d", i))
+		p.Location = &ports.LatLng{Lat: random.Float64()*180 - 90, Lng: random.Float64()*360 - 180}
+		if i%10 == 0 {
+			// Ports near the poles and the antimeridian
+			p.Location = &ports.LatLng{Lat: 89 + random.Float64(), Lng: 179.5 + random.Float64()/2}
+		}
+		storePort(t, repo, p)
+		p.Version = 1
+		stored = append(stored, *p)
+	}
+
+	withoutLocation := newPortWithID("NOLOC")
+	withoutLocation.Location = nil
+	storePort(t, repo, withoutLocation)
+
+	deleted := newPortWithID("DELET")
+	storePort(t, repo, deleted)
+	deletePort(t, repo, "DELET")
+
+	for _, q := range []ports.NearbyQuery{
+		{Location: &ports.LatLng{Lat: 25.4, Lng: 55.5}, Limit: 5},
+		{Location: &ports.LatLng{Lat: 25.4, Lng: 55.5}, RadiusKm: 1500, Limit: 1000},
+		{Location: &ports.LatLng{Lat: 89.9, Lng: -179.9}, Limit: 20},
+		{Location: &ports.LatLng{Lat: -90, Lng: 0}, Limit: 3},
+		{Location: &ports.LatLng{Lat: 0, Lng: 180}, RadiusKm: 3000, Limit: 10},
+	} {
+		t.Run(fmt.Sprintf("%+v", *q.Location), func(t *testing.T) {
+			// When
+			nearby, err := repo.FindNearbyPorts(ctx, q)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, nearestByBruteForce(stored, q), nearby)
+		})
+	}
+}
+
+func nearestByBruteForce(ps []ports.Port, q ports.NearbyQuery) []ports.NearbyPort {
+	result := []ports.NearbyPort{}
+	for _, p := range ps {
+		d := ports.DistanceKm(*q.Location, *p.Location)
+		if q.RadiusKm == 0 || d <= q.RadiusKm {
+			result = append(result, ports.NearbyPort{Port: p, DistanceKm: d})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DistanceKm != result[j].DistanceKm {
+			return result[i].DistanceKm < result[j].DistanceKm
+		}
+		return result[i].Port.ID < result[j].Port.ID
+	})
+	if len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result
+}
+
+func TestInMemoryPortsRepository_SearchPorts(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repo := adapter.NewInMemoryPortsRepository()
+	random := rand.New(rand.NewSource(1))
+	pick := func(values ...string) string {
+		return values[random.Intn(len(values))]
+	}
+
+	live := make(map[string]ports.Port)
+	for _, i := range random.Perm(300) {
+		p := newPortWithID(fmt.Sprintf("P%04d", i))
+		p.Country = pick("Poland", "Spain", "")
+		p.Province = pick("North", "South", "")
+		p.Regions = []string{pick("Europe", "Baltic", "Mediterranean")}
+		p.Timezone = pick("Europe/Warsaw", "Europe/Madrid")
+		p.Code = pick("1", "2", "3", "")
+		p.Name = pick("Port", "Harbour", "Portal") + fmt.Sprint(i)
+		p.Alias = []string{pick("port", "pier", "Dock", "")}
+		storePort(t, repo, p)
+		p.Version = 1
+		live[p.ID] = *p
+	}
+	for _, i := range random.Perm(300)[:60] {
+		id := fmt.Sprintf("P%04d", i)
+		switch i % 3 {
+		case 0:
+			updated, err := repo.UpdatePort(ctx, id, func(p *ports.Port) error {
+				p.Name = "Renamed"
+				p.Country = "Portugal"
+				p.Alias = nil
+				return nil
+			})
+			require.NoError(t, err)
+			live[id] = updated
+		case 1:
+			deletePort(t, repo, id)
+			delete(live, id)
+		default:
+			purgePort(t, repo, id)
+			delete(live, id)
+		}
+	}
+
+	for _, f := range []ports.PortFilter{
+		{},
+		{Country: "Poland"},
+		{Country: "Portugal", Timezone: "Europe/Madrid"},
+		{Province: "North", Code: "2"},
+		{Region: "Baltic", Country: "Spain"},
+		{NamePrefix: "port"},
+		{NamePrefix: "PORTAL1"},
+		{NamePrefix: "dock", Region: "Mediterranean"},
+		{NamePrefix: "re", Code: "3"},
+		{Country: "Germany"},
+		{NamePrefix: "x"},
+	} {
+		t.Run(fmt.Sprintf("%+v", f), func(t *testing.T) {
+			// When
+			var found []ports.Port
+			cursor := ports.Cursor{Limit: 7}
+			for {
+				page, err := repo.SearchPorts(ctx, f, cursor)
+				require.NoError(t, err)
+				found = append(found, page...)
+				if len(page) < cursor.Limit {
+					break
+				}
+				cursor.AfterID = page[len(page)-1].ID
+			}
+
+			// Then
+			assert.Equal(t, searchByBruteForce(live, f), found)
+		})
+	}
+}
+
+func searchByBruteForce(ps map[string]ports.Port, f ports.PortFilter) []ports.Port {
+	var result []ports.Port
+	for _, p := range ps {
+		if f.Matches(p) {
+			result = append(result, p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func TestInMemoryPortsRepository_SuggestPorts(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repo := adapter.NewInMemoryPortsRepository()
+	random := rand.New(rand.NewSource(1))
+	syllables := []string{"ab", "u", "dha", "bi", "je", "bel", "a", "li", "ma", "ri", "na", "por", "to", "ñ", "é"}
+	word := func() string {
+		var w string
+		for n := 1 + random.Intn(4); n > 0; n-- {
+			w += syllables[random.Intn(len(syllables))]
+		}
+		return w
+	}
+
+	live := make(map[string]ports.Port)
+	for _, i := range random.Perm(400) {
+		p := newPortWithID(fmt.Sprintf("P%04d", i))
+		p.Name = word() + " " + word()
+		p.City = word()
+		p.Province = word()
+		p.Alias = []string{word()}
+		storePort(t, repo, p)
+		p.Version = 1
+		live[p.ID] = *p
+	}
+	for _, i := range random.Perm(400)[:80] {
+		id := fmt.Sprintf("P%04d", i)
+		if i%2 == 0 {
+			updated, err := repo.UpdatePort(ctx, id, func(p *ports.Port) error {
+				p.Name = word()
+				return nil
+			})
+			require.NoError(t, err)
+			live[id] = updated
+		} else {
+			deletePort(t, repo, id)
+			delete(live, id)
+		}
+	}
+
+	queries := []string{"a", "dhabi", "Jebel Aly", "ABU DHBAI", "portomarina", "ma ri", "xyz"}
+	for i := 0; i < 50; i++ {
+		// Name of a random port with a typo
+		p, ok := live[fmt.Sprintf("P%04d", random.Intn(400))]
+		if !ok {
+			continue
+		}
+		q := []rune(p.Name)
+		q[random.Intn(len(q))] = []rune("aeiou")[random.Intn(5)]
+		queries = append(queries, string(q))
+	}
+
+	for _, text := range queries {
+		t.Run(text, func(t *testing.T) {
+			q := ports.TextQuery{Text: text, Limit: 20}
+
+			// When
+			suggested, err := repo.SuggestPorts(ctx, q)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, suggestByBruteForce(live, q), suggested)
+		})
+	}
+}
+
+func suggestByBruteForce(ps map[string]ports.Port, q ports.TextQuery) []ports.ScoredPort {
+	var result []ports.ScoredPort
+	for _, p := range ps {
+		if score, ok := q.Matcher().Score(p); ok {
+			result = append(result, ports.ScoredPort{Port: p, Score: score})
+		}
+	}
+	ports.SortScoredPorts(result)
+	if len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result
+}
@@ -0,0 +1,476 @@
+package adapter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/sirupsen/logrus"
+)
+
+// The write-ahead log (WAL) is stored in a directory as a sequence of segment files: wal-<seq>.log.
+// Appends go to the segment with the highest sequence number. Snapshot file snapshot-<seq>.snap contains
+// the repository state after applying all segments up to <seq>, so these segments are removed when it is written.
+//
+// Both segments and snapshots consist of frames: 4 bytes of payload length, 4 bytes of payload CRC-32C checksum
+// (both big-endian) and a JSON-encoded walEntry payload. A torn or corrupted frame at the end of the last segment
+// is a result of a crash during append, so it is truncated on recovery.
+const (
+	walSegmentPrefix = "wal-"
+	walSegmentExt    = ".log"
+	snapshotPrefix   = "snapshot-"
+	snapshotExt      = ".snap"
+	tmpExt           = ".tmp"
+
+	frameHeaderSize  = 8
+	maxFramePayload  = 64 << 20
+	walFilePerm      = 0o600
+	walDirectoryPerm = 0o700
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+type walOp string
+
+const (
+	walOpPut    walOp = "put"
+	walOpRemove walOp = "remove"
+	walOpAck    walOp = "ack"
+	// walOpOutbox sets the outbox state. It is written only to snapshots.
+	walOpOutbox walOp = "outbox"
+	// walOpHistory sets the history of the port. It is written only to snapshots.
+	walOpHistory walOp = "history"
+)
+
+type walEntry struct {
+	Op walOp `json:"op"`
+	// Port is the new port state of put operation.
+	Port *portRecord `json:"port,omitempty"`
+	// ID is the port ID of remove and history operations.
+	ID string `json:"id,omitempty"`
+	// Change is the event of put or remove operation added to the outbox, if the outbox is enabled.
+	Change *portChangeRecord `json:"change,omitempty"`
+	// History are the history entries of the port appended by put or remove operation, or all history entries
+	// of the port of history operation.
+	History []historyRecord `json:"history,omitempty"`
+	// Sequences are the sequences of the events removed from the outbox by ack operation.
+	Sequences []int64 `json:"sequences,omitempty"`
+	// Outbox is the outbox state of outbox operation.
+	Outbox *outboxRecord `json:"outbox,omitempty"`
+}
+
+// outboxRecord is the state of the repository outbox.
+type outboxRecord struct {
+	LastSequence int64              `json:"last_sequence"`
+	Pending      []portChangeRecord `json:"pending,omitempty"`
+}
+
+// snapshotState is the repository state written to a snapshot.
+type snapshotState struct {
+	ports   []portRecord
+	history map[string][]historyRecord
+	outbox  outboxRecord
+}
+
+// writeAheadLog is a portsJournal that appends changes to WAL segment files.
+type writeAheadLog struct {
+	dir  string
+	sync bool
+
+	segment     *os.File
+	segmentSeq  uint64
+	segmentSize int64
+	log         *logrus.Entry
+}
+
+// openWAL recovers the state from the latest snapshot and WAL segments in given directory by passing every
+// recorded entry to apply. Then it starts a new segment for appends.
+func openWAL(dir string, sync bool, log *logrus.Entry, apply func(walEntry) error) (*writeAheadLog, error) {
+	if err := os.MkdirAll(dir, walDirectoryPerm); err != nil {
+		return nil, fmt.Errorf("create WAL directory: %w", err)
+	}
+
+	segments, snapshots, err := listWALFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshotSeq uint64
+	if len(snapshots) > 0 {
+		snapshotSeq = snapshots[len(snapshots)-1]
+		log.WithField("snapshot-seq", snapshotSeq).Debug("Loading ports snapshot")
+		if err = replayFile(filepath.Join(dir, snapshotFileName(snapshotSeq)), false, log, apply); err != nil {
+			return nil, fmt.Errorf("load snapshot: %w", err)
+		}
+	}
+
+	lastSeq := snapshotSeq
+	for i, seq := range segments {
+		if seq <= snapshotSeq {
+			// The segment is covered by the snapshot - a crash occurred before it was removed
+			if err = os.Remove(filepath.Join(dir, walSegmentFileName(seq))); err != nil {
+				return nil, fmt.Errorf("remove compacted WAL segment: %w", err)
+			}
+			continue
+		}
+
+		log.WithField("segment-seq", seq).Debug("Replaying WAL segment")
+		isLast := i == len(segments)-1
+		if err = replayFile(filepath.Join(dir, walSegmentFileName(seq)), isLast, log, apply); err != nil {
+			return nil, fmt.Errorf("replay WAL segment %v: %w", seq, err)
+		}
+		lastSeq = seq
+	}
+
+	w := &writeAheadLog{
+		dir:  dir,
+		sync: sync,
+		log:  log,
+	}
+	if err = w.startSegment(lastSeq + 1); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// listWALFiles returns sorted sequence numbers of WAL segments and snapshots. Leftover temporary files are removed.
+func listWALFiles(dir string) (segments, snapshots []uint64, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read WAL directory: %w", err)
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case strings.HasSuffix(name, tmpExt):
+			// Snapshot that was not completed due to a crash
+			if err = os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, nil, fmt.Errorf("remove temporary file: %w", err)
+			}
+		case strings.HasPrefix(name, walSegmentPrefix) && strings.HasSuffix(name, walSegmentExt):
+			seq, err := parseSeq(name, walSegmentPrefix, walSegmentExt)
+			if err != nil {
+				return nil, nil, err
+			}
+			segments = append(segments, seq)
+		case strings.HasPrefix(name, snapshotPrefix) && strings.HasSuffix(name, snapshotExt):
+			seq, err := parseSeq(name, snapshotPrefix, snapshotExt)
+			if err != nil {
+				return nil, nil, err
+			}
+			snapshots = append(snapshots, seq)
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i] < snapshots[j] })
+	return segments, snapshots, nil
+}
+
+func parseSeq(name, prefix, ext string) (uint64, error) {
+	seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse sequence number of WAL file %v: %w", name, err)
+	}
+	return seq, nil
+}
+
+func walSegmentFileName(seq uint64) string {
+	return fmt.Sprintf("%s%016d%s", walSegmentPrefix, seq, walSegmentExt)
+}
+
+func snapshotFileName(seq uint64) string {
+	return fmt.Sprintf("%s%016d%s", snapshotPrefix, seq, snapshotExt)
+}
+
+// replayFile passes every entry of the file to apply. If truncateTorn is set, a torn or corrupted frame at the end
+// of the file is truncated, otherwise it is reported as an error.
+func replayFile(path string, truncateTorn bool, log *logrus.Entry, apply func(walEntry) error) (err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer func() {
+		cErr := file.Close()
+		if cErr != nil && err == nil {
+			err = fmt.Errorf("close: %w", cErr)
+		}
+	}()
+
+	validSize, err := readFrames(file, func(payload []byte) error {
+		var e walEntry
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return fmt.Errorf("decode WAL entry: %w", err)
+		}
+		return apply(e)
+	})
+
+	var tornErr tornFrameError
+	if errors.As(err, &tornErr) && truncateTorn {
+		log.WithError(err).WithField("path", path).Warn("Truncating torn WAL segment tail")
+		return os.Truncate(path, validSize)
+	}
+	return err
+}
+
+type tornFrameError struct {
+	reason string
+}
+
+func (e tornFrameError) Error() string {
+	return "torn frame: " + e.reason
+}
+
+// readFrames calls fn with payload of each frame and returns the size of the intact part of the reader.
+// tornFrameError is returned if the last frame is incomplete or corrupted.
+func readFrames(reader io.Reader, fn func(payload []byte) error) (int64, error) {
+	r := bufio.NewReader(reader)
+	var (
+		validSize int64
+		header    [frameHeaderSize]byte
+	)
+	for {
+		_, err := io.ReadFull(r, header[:])
+		if errors.Is(err, io.EOF) {
+			return validSize, nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return validSize, tornFrameError{reason: "incomplete header"}
+		}
+		if err != nil {
+			return validSize, fmt.Errorf("read frame header: %w", err)
+		}
+
+		size := binary.BigEndian.Uint32(header[0:4])
+		if size > maxFramePayload {
+			return validSize, tornFrameError{reason: fmt.Sprintf("payload size %v exceeds the limit", size)}
+		}
+
+		payload := make([]byte, size)
+		_, err = io.ReadFull(r, payload)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return validSize, tornFrameError{reason: "incomplete payload"}
+		}
+		if err != nil {
+			return validSize, fmt.Errorf("read frame payload: %w", err)
+		}
+
+		if crc32.Checksum(payload, crc32cTable) != binary.BigEndian.Uint32(header[4:8]) {
+			return validSize, tornFrameError{reason: "checksum mismatch"}
+		}
+
+		if err = fn(payload); err != nil {
+			return validSize, err
+		}
+		validSize += frameHeaderSize + int64(size)
+	}
+}
+
+func encodeFrame(e walEntry) ([]byte, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encode WAL entry: %w", err)
+	}
+
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(payload, crc32cTable))
+	return append(frame, payload...), nil
+}
+
+func (w *writeAheadLog) putPort(p *ports.Port, deleted bool, c portChange) error {
+	rec := newPortRecord(p, deleted)
+	return w.append(walEntry{
+		Op:      walOpPut,
+		Port:    &rec,
+		Change:  changeRecord(c.event),
+		History: []historyRecord{newHistoryRecord(c.entry)},
+	})
+}
+
+func (w *writeAheadLog) removePort(id string, c portChange) error {
+	return w.append(walEntry{
+		Op:      walOpRemove,
+		ID:      id,
+		Change:  changeRecord(c.event),
+		History: []historyRecord{newHistoryRecord(c.entry)},
+	})
+}
+
+func (w *writeAheadLog) ackChanges(sequences []int64) error {
+	return w.append(walEntry{Op: walOpAck, Sequences: sequences})
+}
+
+func changeRecord(change *ports.PortChanged) *portChangeRecord {
+	if change == nil {
+		return nil
+	}
+	rec := newPortChangeRecord(*change)
+	return &rec
+}
+
+func (w *writeAheadLog) append(e walEntry) error {
+	frame, err := encodeFrame(e)
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.segment.Write(frame); err != nil {
+		// Drop the partially written frame, so that following appends are not lost behind a torn frame
+		if tErr := w.segment.Truncate(w.segmentSize); tErr != nil {
+			w.log.WithError(tErr).Error("Failed to truncate WAL segment after failed append")
+		}
+		return fmt.Errorf("append to WAL segment: %w", err)
+	}
+	w.segmentSize += int64(len(frame))
+
+	if w.sync {
+		if err = w.segment.Sync(); err != nil {
+			return fmt.Errorf("sync WAL segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// hasChanges reports whether any entry was appended since the last rotation.
+func (w *writeAheadLog) hasChanges() bool {
+	return w.segmentSize > 0
+}
+
+// rotate starts a new segment and returns the sequence number of the previous one.
+func (w *writeAheadLog) rotate() (uint64, error) {
+	prev, prevSeq := w.segment, w.segmentSeq
+	if err := w.startSegment(prevSeq + 1); err != nil {
+		return 0, err
+	}
+
+	if err := closeSynced(prev); err != nil {
+		return 0, fmt.Errorf("close WAL segment %v: %w", prevSeq, err)
+	}
+	return prevSeq, nil
+}
+
+func (w *writeAheadLog) startSegment(seq uint64) error {
+	path := filepath.Join(w.dir, walSegmentFileName(seq))
+	segment, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, walFilePerm)
+	if err != nil {
+		return fmt.Errorf("create WAL segment: %w", err)
+	}
+	if err = syncDir(w.dir); err != nil {
+		_ = segment.Close()
+		return err
+	}
+
+	w.segment, w.segmentSeq, w.segmentSize = segment, seq, 0
+	return nil
+}
+
+// writeSnapshot writes given state as the snapshot covering WAL segments up to seq and removes these segments together
+// with older snapshots.
+func (w *writeAheadLog) writeSnapshot(seq uint64, state snapshotState) error {
+	path := filepath.Join(w.dir, snapshotFileName(seq))
+	if err := writeSnapshotFile(path+tmpExt, state); err != nil {
+		return err
+	}
+	if err := os.Rename(path+tmpExt, path); err != nil {
+		return fmt.Errorf("rename snapshot file: %w", err)
+	}
+	if err := syncDir(w.dir); err != nil {
+		return err
+	}
+
+	segments, snapshots, err := listWALFiles(w.dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range segments {
+		if s <= seq {
+			if err = os.Remove(filepath.Join(w.dir, walSegmentFileName(s))); err != nil {
+				return fmt.Errorf("remove compacted WAL segment: %w", err)
+			}
+		}
+	}
+	for _, s := range snapshots {
+		if s < seq {
+			if err = os.Remove(filepath.Join(w.dir, snapshotFileName(s))); err != nil {
+				return fmt.Errorf("remove old snapshot: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func writeSnapshotFile(path string, state snapshotState) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, walFilePerm)
+	if err != nil {
+		return fmt.Errorf("create snapshot file: %w", err)
+	}
+
+	entries := make([]walEntry, 0, len(state.ports)+len(state.history)+1)
+	for i := range state.ports {
+		entries = append(entries, walEntry{Op: walOpPut, Port: &state.ports[i]})
+	}
+	for id, history := range state.history {
+		entries = append(entries, walEntry{Op: walOpHistory, ID: id, History: history})
+	}
+	if state.outbox.LastSequence != 0 {
+		entries = append(entries, walEntry{Op: walOpOutbox, Outbox: &state.outbox})
+	}
+
+	bw := bufio.NewWriter(file)
+	for _, e := range entries {
+		frame, err := encodeFrame(e)
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+		if _, err = bw.Write(frame); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("write snapshot file: %w", err)
+		}
+	}
+
+	if err = bw.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write snapshot file: %w", err)
+	}
+	if err = closeSynced(file); err != nil {
+		return fmt.Errorf("close snapshot file: %w", err)
+	}
+	return nil
+}
+
+func (w *writeAheadLog) close() error {
+	return closeSynced(w.segment)
+}
+
+func closeSynced(f *os.File) error {
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir persists the directory entries, so that created and renamed files survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open directory: %w", err)
+	}
+	if err = closeSynced(d); err != nil {
+		return fmt.Errorf("sync directory: %w", err)
+	}
+	return nil
+}
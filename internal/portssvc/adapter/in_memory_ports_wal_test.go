@@ -0,0 +1,379 @@
+package adapter_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/adapter"
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryPortsRepository_WALRecovery(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// crash modifies the WAL directory after the repository crashed
+		crash       func(t *testing.T, dir string)
+		expectedIDs []string
+	}{
+		{
+			name:        "crash without closing",
+			crash:       func(t *testing.T, dir string) {},
+			expectedIDs: []string{"AEAJM", "AEAUH", "AEDXB"},
+		}, {
+			name: "torn frame header at the segment end",
+			crash: func(t *testing.T, dir string) {
+				appendToLastSegment(t, dir, []byte{0, 0, 0})
+			},
+			expectedIDs: []string{"AEAJM", "AEAUH", "AEDXB"},
+		}, {
+			name: "torn frame payload at the segment end",
+			crash: func(t *testing.T, dir string) {
+				appendToLastSegment(t, dir, []byte{0, 0, 0, 100, 1, 2, 3, 4, '{', '"'})
+			},
+			expectedIDs: []string{"AEAJM", "AEAUH", "AEDXB"},
+		}, {
+			name: "corrupted last frame",
+			crash: func(t *testing.T, dir string) {
+				path := lastSegment(t, dir)
+				content, err := os.ReadFile(path)
+				require.NoError(t, err)
+				content[len(content)-2] ^= 0xff
+				require.NoError(t, os.WriteFile(path, content, 0o600))
+			},
+			expectedIDs: []string{"AEAJM", "AEAUH"},
+		}, {
+			name: "incomplete snapshot file",
+			crash: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "snapshot-0000000000000001.snap.tmp")
+				require.NoError(t, os.WriteFile(path, []byte{0, 0, 1}, 0o600))
+			},
+			expectedIDs: []string{"AEAJM", "AEAUH", "AEDXB"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			ctx := context.Background()
+			dir := t.TempDir()
+			repo := newWALRepository(t, dir)
+			for _, id := range []string{"AEAJM", "AEAUH", "AEDXB"} {
+				storePort(t, repo, newPortWithID(id))
+			}
+
+			// When
+			tt.crash(t, dir)
+			repo = newWALRepository(t, dir)
+
+			// Then
+			ps, err := repo.ListPorts(ctx, ports.Cursor{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedIDs, portIDs(ps))
+
+			// Ports stored after recovery survive the next crash
+			storePort(t, repo, newPortWithID("AEFJR"))
+			repo = newWALRepository(t, dir)
+
+			ps, err = repo.ListPorts(ctx, ports.Cursor{})
+			require.NoError(t, err)
+			assert.Equal(t, append(tt.expectedIDs, "AEFJR"), portIDs(ps))
+		})
+	}
+}
+
+func TestInMemoryPortsRepository_Snapshot(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := newWALRepository(t, dir)
+	storePort(t, repo, newPortWithID("AEAJM"))
+	storePort(t, repo, newPortWithID("AEAUH"))
+
+	// When
+	require.NoError(t, repo.Snapshot())
+
+	updated := newPortWithID("AEAJM")
+	updated.Name = "Ajman Port"
+	storePort(t, repo, updated)
+	storePort(t, repo, newPortWithID("AEDXB"))
+
+	repo = newWALRepository(t, dir)
+
+	// Then
+	ps, err := repo.ListPorts(ctx, ports.Cursor{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AEAJM", "AEAUH", "AEDXB"}, portIDs(ps))
+	assert.Equal(t, "Ajman Port", ps[0].Name)
+
+	snapshots, err := filepath.Glob(filepath.Join(dir, "snapshot-*"))
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1, "old snapshots should be removed")
+
+	segments, err := filepath.Glob(filepath.Join(dir, "wal-*"))
+	require.NoError(t, err)
+	assert.Len(t, segments, 2, "segments covered by the snapshot should be removed")
+}
+
+func TestInMemoryPortsRepository_DeletedPortsRecovery(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		snapshot bool
+	}{
+		{
+			name: "recovery from WAL",
+		}, {
+			name:     "recovery from snapshot",
+			snapshot: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			ctx := context.Background()
+			dir := t.TempDir()
+			repo := newWALRepository(t, dir)
+			for _, id := range []string{"AEAJM", "AEAUH", "AEDXB"} {
+				storePort(t, repo, newPortWithID(id))
+			}
+			deletePort(t, repo, "AEAJM")
+			purgePort(t, repo, "AEAUH")
+			if tt.snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+
+			// When
+			repo = newWALRepository(t, dir)
+
+			// Then
+			ps, err := repo.ListPorts(ctx, ports.Cursor{})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"AEDXB"}, portIDs(ps))
+
+			restored, err := repo.RestorePort(ctx, "AEAJM")
+			assert.NoError(t, err, "soft-deleted port should be restorable")
+			assert.Equal(t, storedPortWithID("AEAJM", 3), restored, "delete and restore increment the version")
+			_, err = repo.RestorePort(ctx, "AEAUH")
+			assert.ErrorIs(t, err, ports.ErrPortNotFound)
+		})
+	}
+}
+
+func TestInMemoryPortsRepository_OutboxRecovery(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		snapshot bool
+	}{
+		{
+			name: "recovery from WAL",
+		}, {
+			name:     "recovery from snapshot",
+			snapshot: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			ctx := context.Background()
+			dir := t.TempDir()
+			repo := newWALRepository(t, dir)
+			repo.EnableOutbox()
+			for _, id := range []string{"AEAJM", "AEAUH"} {
+				storePort(t, repo, newPortWithID(id))
+			}
+			purgePort(t, repo, "AEAJM")
+			require.NoError(t, repo.AckPortChanges(ctx, []int64{1}))
+			if tt.snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+
+			// When
+			repo = newWALRepository(t, dir)
+			repo.EnableOutbox()
+
+			// Then
+			pending, err := repo.PendingPortChanges(ctx, ports.OutboxBatchSize)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{2, 3}, changeSequences(pending))
+			assert.Equal(t, []ports.EventType{ports.EventCreated, ports.EventDeleted}, changeTypes(pending))
+			assert.Equal(t, storedPortWithID("AEAUH", 1), pending[0].Port)
+			assert.Equal(t, storedPortWithID("AEAJM", 1), pending[1].Port)
+
+			// Sequences continue after recovery, also when all events were acknowledged
+			require.NoError(t, repo.AckPortChanges(ctx, []int64{2, 3}))
+			if tt.snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+			repo = newWALRepository(t, dir)
+			repo.EnableOutbox()
+			renamed := newPortWithID("AEAUH")
+			renamed.Name = "Abu Dhabi"
+			storePort(t, repo, renamed)
+
+			pending, err = repo.PendingPortChanges(ctx, ports.OutboxBatchSize)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{4}, changeSequences(pending))
+			assert.Equal(t, []ports.EventType{ports.EventUpdated}, changeTypes(pending))
+		})
+	}
+}
+
+func TestInMemoryPortsRepository_HistoryRecovery(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		snapshot bool
+	}{
+		{
+			name: "recovery from WAL",
+		}, {
+			name:     "recovery from snapshot",
+			snapshot: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			ctx := ports.WithCaller(context.Background(), "alice")
+			dir := t.TempDir()
+			repo := newWALRepository(t, dir)
+			for _, id := range []string{"AEAJM", "AEAUH"} {
+				_, _, err := repo.StorePort(ctx, newPortWithID(id))
+				require.NoError(t, err)
+			}
+			deletePort(t, repo, "AEAJM")
+			purgePort(t, repo, "AEAJM")
+			history, err := repo.GetPortHistory(ctx, "AEAJM", ports.HistoryCursor{})
+			require.NoError(t, err)
+			if tt.snapshot {
+				require.NoError(t, repo.Snapshot())
+			}
+
+			// When
+			repo = newWALRepository(t, dir)
+
+			// Then
+			recovered, err := repo.GetPortHistory(ctx, "AEAJM", ports.HistoryCursor{})
+			require.NoError(t, err)
+			assert.Equal(t, history, recovered)
+			assert.Equal(t, []int64{1, 2, 3}, historySequences(recovered))
+			assert.Equal(t, "alice", recovered[0].Caller)
+
+			// Sequences continue after recovery, also after the port was purged
+			storePort(t, repo, newPortWithID("AEAJM"))
+			recovered, err = repo.GetPortHistory(ctx, "AEAJM", ports.HistoryCursor{AfterSequence: 2})
+			require.NoError(t, err)
+			assert.Equal(t, []int64{3, 4}, historySequences(recovered))
+			assert.Nil(t, recovered[1].Previous)
+		})
+	}
+}
+
+func TestInMemoryPortsRepository_PeriodicSnapshot(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	repo, err := adapter.NewInMemoryPortsRepositoryWithWAL(adapter.WALOptions{
+		Dir:              dir,
+		SnapshotInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, repo.Close())
+	}()
+
+	// When
+	storePort(t, repo, newAjmanPort())
+
+	// Then
+	assert.Eventually(t, func() bool {
+		snapshots, err := filepath.Glob(filepath.Join(dir, "snapshot-*.snap"))
+		return err == nil && len(snapshots) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+// newWALRepository opens the repository with WAL in given directory. The repository is not closed, to simulate
+// a crash when the next one is opened in the same directory.
+func newWALRepository(t *testing.T, dir string) *adapter.InMemoryPortsRepository {
+	repo, err := adapter.NewInMemoryPortsRepositoryWithWAL(adapter.WALOptions{Dir: dir})
+	require.NoError(t, err)
+	return repo
+}
+
+func lastSegment(t *testing.T, dir string) string {
+	segments, err := filepath.Glob(filepath.Join(dir, "wal-*.log"))
+	require.NoError(t, err)
+	require.NotEmpty(t, segments)
+
+	// The last segment is an empty one started on recovery, so look for the last non-empty segment
+	for i := len(segments) - 1; i >= 0; i-- {
+		info, err := os.Stat(segments[i])
+		require.NoError(t, err)
+		if info.Size() > 0 {
+			return segments[i]
+		}
+	}
+	t.Fatal("all WAL segments are empty")
+	return ""
+}
+
+func appendToLastSegment(t *testing.T, dir string, data []byte) {
+	f, err := os.OpenFile(lastSegment(t, dir), os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func newPortWithID(id string) *ports.Port {
+	p := newAjmanPort()
+	p.ID = id
+	p.Unlocs = []string{id}
+	return p
+}
+
+func storePort(t *testing.T, repo ports.Repository, p *ports.Port) {
+	t.Helper()
+	_, _, err := repo.StorePort(context.Background(), p)
+	require.NoError(t, err)
+}
+
+func deletePort(t *testing.T, repo ports.Repository, id string) {
+	t.Helper()
+	_, err := repo.DeletePort(context.Background(), id)
+	require.NoError(t, err)
+}
+
+func purgePort(t *testing.T, repo ports.Repository, id string) {
+	t.Helper()
+	_, err := repo.PurgePort(context.Background(), id)
+	require.NoError(t, err)
+}
+
+// storedPortWithID returns the port with given ID and the version assigned by the repository.
+func storedPortWithID(id string, version int64) ports.Port {
+	p := newPortWithID(id)
+	p.Version = version
+	return *p
+}
+
+func changeSequences(events []ports.PortChanged) []int64 {
+	sequences := make([]int64, 0, len(events))
+	for _, e := range events {
+		sequences = append(sequences, e.Sequence)
+	}
+	return sequences
+}
+
+func changeTypes(events []ports.PortChanged) []ports.EventType {
+	types := make([]ports.EventType, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func historySequences(entries []ports.HistoryEntry) []int64 {
+	result := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.Sequence)
+	}
+	return result
+}
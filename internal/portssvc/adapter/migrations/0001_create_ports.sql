@@ -0,0 +1,13 @@
+CREATE TABLE ports (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    city        TEXT NOT NULL DEFAULT '',
+    country     TEXT NOT NULL DEFAULT '',
+    alias       TEXT[],
+    regions     TEXT[],
+    coordinates DOUBLE PRECISION[],
+    province    TEXT NOT NULL DEFAULT '',
+    timezone    TEXT NOT NULL DEFAULT '',
+    unlocs      TEXT[],
+    code        TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,3 @@
+ALTER TABLE ports ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX ports_live_id_idx ON ports (id) WHERE deleted_at IS NULL;
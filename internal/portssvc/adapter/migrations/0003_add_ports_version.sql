@@ -0,0 +1 @@
+ALTER TABLE ports ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+-- Coordinates are [longitude, latitude], so the index narrows nearby ports queries to the latitude band
+CREATE INDEX ports_latitude_idx ON ports ((coordinates[2])) WHERE deleted_at IS NULL AND cardinality(coordinates) = 2;
@@ -0,0 +1,7 @@
+-- Indexes of the fields filtered by SearchPorts. Name prefixes are matched on lower-cased names.
+CREATE INDEX ports_country_idx ON ports (country) WHERE deleted_at IS NULL;
+CREATE INDEX ports_province_idx ON ports (province) WHERE deleted_at IS NULL;
+CREATE INDEX ports_timezone_idx ON ports (timezone) WHERE deleted_at IS NULL;
+CREATE INDEX ports_code_idx ON ports (code) WHERE deleted_at IS NULL;
+CREATE INDEX ports_regions_idx ON ports USING GIN (regions) WHERE deleted_at IS NULL;
+CREATE INDEX ports_lower_name_idx ON ports (lower(name) text_pattern_ops) WHERE deleted_at IS NULL;
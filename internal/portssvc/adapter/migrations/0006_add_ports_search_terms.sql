@@ -0,0 +1,5 @@
+-- Folded words of port names, aliases, cities and provinces, matched by SuggestPorts with trigram similarity.
+-- They are NULL until the service fills them in on startup, as folding diacritics is done by the service.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+ALTER TABLE ports ADD COLUMN search_terms TEXT;
+CREATE INDEX ports_search_terms_idx ON ports USING GIN (search_terms gin_trgm_ops) WHERE deleted_at IS NULL;
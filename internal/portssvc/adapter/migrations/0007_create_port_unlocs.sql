@@ -0,0 +1,15 @@
+-- Unlocs of ports that are not soft-deleted, other than their IDs. Each code can be listed by a single port,
+-- but the port with that ID is preferred over it.
+CREATE TABLE port_unlocs (
+    unloc   TEXT PRIMARY KEY,
+    port_id TEXT NOT NULL REFERENCES ports (id) ON DELETE CASCADE
+);
+
+CREATE INDEX port_unlocs_port_id_idx ON port_unlocs (port_id);
+
+-- Codes duplicated before they had to be unique are kept by the port with the lowest ID
+INSERT INTO port_unlocs (unloc, port_id)
+SELECT DISTINCT ON (codes.unloc) codes.unloc, ports.id
+FROM ports, unnest(ports.unlocs) AS codes (unloc)
+WHERE ports.deleted_at IS NULL AND codes.unloc <> ports.id
+ORDER BY codes.unloc, ports.id;
@@ -0,0 +1,8 @@
+-- Outbox of port change events that are not published yet. Events are inserted in the same transaction
+-- as the port changes.
+CREATE TABLE port_changes (
+    sequence   BIGSERIAL PRIMARY KEY,
+    type       TEXT        NOT NULL,
+    port       JSONB       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
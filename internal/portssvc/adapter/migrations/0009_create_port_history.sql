@@ -0,0 +1,13 @@
+-- Audit history of port changes. Entries are inserted in the same transaction as the port changes, and they are kept
+-- after the ports are purged.
+CREATE TABLE port_history (
+    sequence   BIGSERIAL PRIMARY KEY,
+    port_id    TEXT        NOT NULL,
+    type       TEXT        NOT NULL,
+    previous   JSONB,
+    port       JSONB,
+    caller     TEXT        NOT NULL DEFAULT '',
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX port_history_port_id_idx ON port_history (port_id, sequence);
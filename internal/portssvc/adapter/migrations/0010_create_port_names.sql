@@ -0,0 +1,16 @@
+-- Lower-cased names and aliases of ports, matched by SearchPorts name prefixes. Aliases are kept as rows,
+-- so that their prefixes are matched with the index like the names.
+CREATE TABLE port_names (
+    folded_name TEXT NOT NULL,
+    port_id     TEXT NOT NULL REFERENCES ports (id) ON DELETE CASCADE,
+    PRIMARY KEY (folded_name, port_id)
+);
+
+CREATE INDEX port_names_folded_name_idx ON port_names (folded_name text_pattern_ops);
+CREATE INDEX port_names_port_id_idx ON port_names (port_id);
+
+INSERT INTO port_names (folded_name, port_id)
+SELECT DISTINCT lower(names.name), id
+FROM ports, unnest(array_prepend(ports.name, ports.alias)) AS names (name);
+
+DROP INDEX ports_lower_name_idx;
@@ -0,0 +1,190 @@
+package adapter_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/adapter"
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNATSPublisher(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	server := startFakeNATSServer(t)
+	publisher, err := adapter.NewNATSPublisher("nats://user:secret@"+server.address, "ports.changed")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, publisher.Close())
+	}()
+
+	event := ports.PortChanged{
+		Sequence: 7,
+		Type:     ports.EventCreated,
+		Port:     storedPortWithID("AEAJM", 1),
+		Time:     time.Date(2022, 9, 19, 13, 19, 57, 0, time.UTC),
+	}
+
+	// When
+	err = publisher.PublishPortChanged(ctx, event)
+
+	// Then
+	require.NoError(t, err)
+	msg := <-server.messages
+	assert.Equal(t, "ports.changed", msg.subject)
+	assert.JSONEq(t, `{
+		"sequence": 7,
+		"type": "created",
+		"port": {
+			"id": "AEAJM",
+			"name": "Ajman",
+			"city": "Ajman",
+			"country": "United Arab Emirates",
+			"alias": ["foo-alias", "bar-alias"],
+			"regions": ["foo-region", "bar-region"],
+			"coordinates": [55.5136433, 25.4052165],
+			"province": "Ajman",
+			"timezone": "Asia/Dubai",
+			"unlocs": ["AEAJM"],
+			"code": "52000",
+			"version": 1
+		},
+		"time": "2022-09-19T13:19:57Z"
+	}`, msg.payload)
+	assert.Equal(t, "user", msg.connectOptions["user"])
+	assert.Equal(t, "secret", msg.connectOptions["pass"])
+
+	// When
+	server.rejectNext <- "Permissions Violation for Publish to ports.changed"
+	err = publisher.PublishPortChanged(ctx, event)
+
+	// Then
+	// The client reports the server error asynchronously, so only the closed connection is returned
+	assert.Error(t, err)
+
+	// When
+	err = publisher.PublishPortChanged(ctx, event)
+
+	// Then
+	require.NoError(t, err, "publisher should reconnect after failure")
+	msg = <-server.messages
+	assert.Equal(t, 2, msg.connection)
+}
+
+func TestNewNATSPublisher(t *testing.T) {
+	for _, tt := range []struct {
+		url     string
+		subject string
+	}{
+		{url: "http://localhost:4222", subject: "ports.changed"},
+		{url: "nats://", subject: "ports.changed"},
+		{url: "nats://localhost:4222", subject: ""},
+		{url: "nats://localhost:4222", subject: "ports changed"},
+	} {
+		_, err := adapter.NewNATSPublisher(tt.url, tt.subject)
+		assert.Error(t, err, "URL %q, subject %q", tt.url, tt.subject)
+	}
+}
+
+type natsMessage struct {
+	// connection is the number of the connection the message was published on, starting from 1.
+	connection     int
+	connectOptions map[string]interface{}
+	subject        string
+	payload        string
+}
+
+// fakeNATSServer implements NATS server protocol needed by the publisher.
+type fakeNATSServer struct {
+	address  string
+	messages chan natsMessage
+	// rejectNext makes the server answer the next published message with the error and close the connection.
+	rejectNext chan string
+}
+
+func startFakeNATSServer(t *testing.T) *fakeNATSServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, listener.Close())
+	})
+
+	s := &fakeNATSServer{
+		address:    listener.Addr().String(),
+		messages:   make(chan natsMessage, 10),
+		rejectNext: make(chan string, 1),
+	}
+	go func() {
+		for connection := 1; ; connection++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.serve(conn, connection)
+		}
+	}()
+	return s
+}
+
+func (s *fakeNATSServer) serve(conn net.Conn, connection int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	write := func(line string) {
+		_, _ = fmt.Fprintf(conn, "%s\r\n", line)
+	}
+
+	write(`INFO {"server_id":"fake","max_payload":1048576}`)
+	var connectOptions map[string]interface{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		op, args, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		switch op {
+		case "CONNECT":
+			if err = json.Unmarshal([]byte(args), &connectOptions); err != nil {
+				write("-ERR 'Invalid Connect'")
+				return
+			}
+		case "PING":
+			write("PONG")
+		case "PUB":
+			fields := strings.Fields(args)
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err = io.ReadFull(r, payload); err != nil {
+				return
+			}
+
+			select {
+			case reason := <-s.rejectNext:
+				write("-ERR '" + reason + "'")
+				return
+			default:
+			}
+			s.messages <- natsMessage{
+				connection:     connection,
+				connectOptions: connectOptions,
+				subject:        fields[0],
+				payload:        string(payload[:size]),
+			}
+		default:
+			write("-ERR 'Unknown Protocol Operation'")
+			return
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package adapter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+)
+
+// portRecord is a serialized form of the Port used by file-backed repositories.
+// It is decoupled from the domain entity, so that the entity can evolve without breaking stored files.
+// Coordinates are [longitude, latitude] of the port location.
+type portRecord struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	City        string    `json:"city,omitempty"`
+	Country     string    `json:"country,omitempty"`
+	Alias       []string  `json:"alias,omitempty"`
+	Regions     []string  `json:"regions,omitempty"`
+	Coordinates []float64 `json:"coordinates,omitempty"`
+	Province    string    `json:"province,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"`
+	Unlocs      []string  `json:"unlocs,omitempty"`
+	Code        string    `json:"code,omitempty"`
+	Version     int64     `json:"version,omitempty"`
+	// Deleted is set for soft-deleted ports.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+func newPortRecord(p *ports.Port, deleted bool) portRecord {
+	return portRecord{
+		ID:          p.ID,
+		Name:        p.Name,
+		City:        p.City,
+		Country:     p.Country,
+		Alias:       p.Alias,
+		Regions:     p.Regions,
+		Coordinates: p.Location.Coordinates(),
+		Province:    p.Province,
+		Timezone:    p.Timezone,
+		Unlocs:      p.Unlocs,
+		Code:        p.Code,
+		Version:     p.Version,
+		Deleted:     deleted,
+	}
+}
+
+func (r portRecord) toDomain() *ports.Port {
+	// Records are validated before they are stored, so coordinates are either empty or a pair
+	location, _ := ports.LatLngFromCoordinates(r.Coordinates)
+	return &ports.Port{
+		ID:       r.ID,
+		Name:     r.Name,
+		City:     r.City,
+		Country:  r.Country,
+		Alias:    r.Alias,
+		Regions:  r.Regions,
+		Location: location,
+		Province: r.Province,
+		Timezone: r.Timezone,
+		Unlocs:   r.Unlocs,
+		Code:     r.Code,
+		Version:  r.Version,
+	}
+}
+
+// portChangeRecord is a serialized form of the PortChanged event used by repository outboxes and published messages.
+type portChangeRecord struct {
+	Sequence int64      `json:"sequence"`
+	Type     string     `json:"type"`
+	Port     portRecord `json:"port"`
+	Time     time.Time  `json:"time"`
+}
+
+func newPortChangeRecord(e ports.PortChanged) portChangeRecord {
+	return portChangeRecord{
+		Sequence: e.Sequence,
+		Type:     e.Type.String(),
+		Port:     newPortRecord(&e.Port, e.Type == ports.EventDeleted),
+		Time:     e.Time,
+	}
+}
+
+func (r portChangeRecord) toDomain() (ports.PortChanged, error) {
+	t, err := eventTypeFromString(r.Type)
+	if err != nil {
+		return ports.PortChanged{}, err
+	}
+	return ports.PortChanged{
+		Sequence: r.Sequence,
+		Type:     t,
+		Port:     *r.Port.toDomain(),
+		Time:     r.Time,
+	}, nil
+}
+
+func eventTypeFromString(s string) (ports.EventType, error) {
+	for _, t := range []ports.EventType{ports.EventCreated, ports.EventUpdated, ports.EventDeleted} {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown port event type %q", s)
+}
+
+// historyRecord is a serialized form of the HistoryEntry used by file-backed repositories.
+type historyRecord struct {
+	Sequence int64       `json:"sequence"`
+	Type     string      `json:"type"`
+	Previous *portRecord `json:"previous,omitempty"`
+	Port     *portRecord `json:"port,omitempty"`
+	Time     time.Time   `json:"time"`
+	Caller   string      `json:"caller,omitempty"`
+}
+
+func newHistoryRecord(e ports.HistoryEntry) historyRecord {
+	return historyRecord{
+		Sequence: e.Sequence,
+		Type:     e.Type.String(),
+		Previous: optionalPortRecord(e.Previous),
+		Port:     optionalPortRecord(e.Port),
+		Time:     e.Time,
+		Caller:   e.Caller,
+	}
+}
+
+func optionalPortRecord(p *ports.Port) *portRecord {
+	if p == nil {
+		return nil
+	}
+	rec := newPortRecord(p, false)
+	return &rec
+}
+
+func (r historyRecord) toDomain() (ports.HistoryEntry, error) {
+	t, err := eventTypeFromString(r.Type)
+	if err != nil {
+		return ports.HistoryEntry{}, err
+	}
+	e := ports.HistoryEntry{
+		Sequence: r.Sequence,
+		Type:     t,
+		Time:     r.Time,
+		Caller:   r.Caller,
+	}
+	if r.Previous != nil {
+		e.Previous = r.Previous.toDomain()
+	}
+	if r.Port != nil {
+		e.Port = r.Port.toDomain()
+	}
+	return e, nil
+}
@@ -0,0 +1,103 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// migrationsLockID is a key of the PostgreSQL advisory lock that serializes migrations of concurrently starting
+// service instances.
+const migrationsLockID = 7_384_501
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migratePostgres applies schema migrations that were not applied yet.
+// Migrations are SQL scripts from migrations directory, applied in file name order. Each migration is applied
+// in a separate transaction and recorded in schema_migrations table.
+func migratePostgres(ctx context.Context, db *sql.DB) (err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get DB connection: %w", err)
+	}
+	defer func() {
+		cErr := conn.Close()
+		if cErr != nil && err == nil {
+			err = fmt.Errorf("close DB connection: %w", cErr)
+		}
+	}()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationsLockID); err != nil {
+		return fmt.Errorf("acquire migrations lock: %w", err)
+	}
+	defer func() {
+		_, uErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockID)
+		if uErr != nil && err == nil {
+			err = fmt.Errorf("release migrations lock: %w", uErr)
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("list migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		if err = applyMigration(ctx, conn, f); err != nil {
+			return fmt.Errorf("apply migration %v: %w", f, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, file string) (err error) {
+	version := strings.TrimSuffix(path.Base(file), ".sql")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var applied bool
+	err = tx.QueryRowContext(
+		ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version,
+	).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("check migration version: %w", err)
+	}
+	if applied {
+		return tx.Commit()
+	}
+
+	script, err := migrationsFS.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read migration: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, string(script)); err != nil {
+		return fmt.Errorf("execute migration: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+		return fmt.Errorf("record migration version: %w", err)
+	}
+	return tx.Commit()
+}
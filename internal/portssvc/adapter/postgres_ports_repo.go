@@ -0,0 +1,996 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/danielfurman/ports-microservices/internal/logs"
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// portColumns are columns of port values, in portArgs order.
+	portColumns = "id, name, city, country, alias, regions, coordinates, province, timezone, unlocs, code, search_terms"
+	// selectPortColumns are columns read by scanPort.
+	selectPortColumns = "id, name, city, country, alias, regions, coordinates, province, timezone, unlocs, code, version"
+
+	// suggestCandidatesFactor is a number of candidates per requested port that are scored by SuggestPorts.
+	suggestCandidatesFactor = 20
+	// suggestWordSimilarity is a minimal trigram similarity of the query terms and the words of suggested ports.
+	// It is low, so that the candidates include most of the terms with typos.
+	suggestWordSimilarity = 0.2
+
+	// nearbyInitialRadiusKm is a radius of the first latitude band searched by FindNearbyPorts.
+	nearbyInitialRadiusKm = 100.0
+	// nearbyRadiusGrowth is a factor by which the latitude band radius grows when it has too few ports.
+	nearbyRadiusGrowth = 4.0
+)
+
+// PostgresPortsRepository allows to store Ports in PostgreSQL database.
+// It is safe for concurrent use.
+type PostgresPortsRepository struct {
+	db *sql.DB
+	// outboxEnabled enables recording of PortChanged events in port_changes table.
+	outboxEnabled bool
+	log           *logrus.Entry
+}
+
+// NewPostgresPortsRepository connects to PostgreSQL database with given connection URL and applies pending schema
+// migrations. PostgresPortsRepository.Close() should be called when repository is no longer needed.
+func NewPostgresPortsRepository(ctx context.Context, url string) (*PostgresPortsRepository, error) {
+	log := logs.NewLogger("postgres-ports-repo")
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, fmt.Errorf("open DB: %w", err)
+	}
+
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("ping DB: %w", err)
+	}
+
+	log.Debug("Applying DB migrations")
+	if err = migratePostgres(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrate DB: %w", err)
+	}
+
+	r := &PostgresPortsRepository{
+		db:  db,
+		log: log,
+	}
+	if err = r.fillSearchTerms(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("fill search terms: %w", err)
+	}
+	return r, nil
+}
+
+// EnableOutbox makes the repository record PortChanged events of the port changes.
+// It should be called before the repository is used.
+func (r *PostgresPortsRepository) EnableOutbox() {
+	r.outboxEnabled = true
+}
+
+// fillSearchTerms sets search terms of the ports stored before the search_terms column was added.
+// The terms are folded with ports.PortTerms, which cannot be done in SQL.
+func (r *PostgresPortsRepository) fillSearchTerms(ctx context.Context) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+selectPortColumns+" FROM ports WHERE search_terms IS NULL")
+	if err != nil {
+		return fmt.Errorf("query ports without search terms: %w", err)
+	}
+	var ps []ports.Port
+	for rows.Next() {
+		p, err := scanPort(rows)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		ps = append(ps, p)
+	}
+	if err = rows.Close(); err != nil {
+		return fmt.Errorf("close rows: %w", err)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("iterate ports without search terms: %w", err)
+	}
+
+	r.log.WithField("ports-count", len(ps)).Debug("Filling search terms")
+	for _, p := range ps {
+		_, err = r.db.ExecContext(ctx, "UPDATE ports SET search_terms = $2 WHERE id = $1", p.ID, searchTerms(p))
+		if err != nil {
+			return fmt.Errorf("update search terms of port with ID %v: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// StorePort inserts given port or replaces the port with the same ID, incrementing its version, and returns
+// the outcome. The stored port with the same content is not replaced. If the port has non-zero version, the stored
+// port is replaced only if it has that version. The port codes are claimed in the same transaction.
+func (r *PostgresPortsRepository) StorePort(
+	ctx context.Context, port *ports.Port,
+) (ports.Port, ports.StoreOutcome, error) {
+	var (
+		stored  ports.Port
+		created bool
+		outcome ports.StoreOutcome
+	)
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		// The stored port is locked, so that it is not deleted or restored before it is replaced
+		previous, wasDeleted, err := lockPort(ctx, tx, port.ID)
+		if err != nil {
+			return err
+		}
+		if previous != nil && !wasDeleted && (port.Version == 0 || port.Version == previous.Version) &&
+			previous.ContentHash() == port.ContentHash() {
+			stored, outcome = *previous, ports.StoreUnchanged
+			return nil
+		}
+		r.log.WithField("port-id", port.ID).Debug("Storing port")
+		if port.Version != 0 {
+			stored, err = replacePort(ctx, tx, port)
+		} else {
+			stored, created, err = upsertPort(ctx, tx, port, wasDeleted)
+		}
+		if err != nil {
+			return err
+		}
+		if err = claimUnlocs(ctx, tx, port); err != nil {
+			return err
+		}
+		if err = storeNames(ctx, tx, port); err != nil {
+			return err
+		}
+
+		eventType := ports.EventUpdated
+		outcome = ports.StoreUpdated
+		if created {
+			eventType, outcome = ports.EventCreated, ports.StoreCreated
+		}
+		return r.recordChange(ctx, tx, eventType, previous, &stored)
+	})
+	if err != nil {
+		return ports.Port{}, 0, err
+	}
+	return stored, outcome, nil
+}
+
+// recordChange inserts the history entry of the change from the previous port to the new one, which is nil if the
+// port is deleted, and the event of the change to the outbox, if it is enabled.
+func (r *PostgresPortsRepository) recordChange(
+	ctx context.Context, tx *sql.Tx, t ports.EventType, previous, port *ports.Port,
+) error {
+	changed := port
+	if changed == nil {
+		changed = previous
+	}
+	if err := recordHistory(ctx, tx, t, previous, port); err != nil {
+		return err
+	}
+	return r.recordEvent(ctx, tx, t, changed)
+}
+
+// recordHistory inserts the history entry of the port change from the previous port to the new one, which is nil
+// if the port is deleted.
+func recordHistory(ctx context.Context, tx *sql.Tx, t ports.EventType, previous, port *ports.Port) error {
+	changed := port
+	if changed == nil {
+		changed = previous
+	}
+
+	entry := ports.NewHistoryEntry(ctx, t, previous, port)
+	previousValue, err := encodeOptionalPort(entry.Previous)
+	if err != nil {
+		return err
+	}
+	portValue, err := encodeOptionalPort(entry.Port)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO port_history (port_id, type, previous, port, caller, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		changed.ID, t.String(), previousValue, portValue, entry.Caller, entry.Time,
+	)
+	if err != nil {
+		return fmt.Errorf("insert history entry of port with ID %v: %w", changed.ID, err)
+	}
+	return nil
+}
+
+// recordEvent inserts the event of the changed port to the outbox, if it is enabled.
+func (r *PostgresPortsRepository) recordEvent(
+	ctx context.Context, tx *sql.Tx, t ports.EventType, changed *ports.Port,
+) error {
+	if !r.outboxEnabled {
+		return nil
+	}
+	value, err := json.Marshal(newPortRecord(changed, t == ports.EventDeleted))
+	if err != nil {
+		return fmt.Errorf("encode port: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO port_changes (type, port) VALUES ($1, $2)", t.String(), value)
+	if err != nil {
+		return fmt.Errorf("insert change of port with ID %v: %w", changed.ID, err)
+	}
+	return nil
+}
+
+// encodeOptionalPort encodes the port as JSON record, or returns nil for nil port, so that it is stored as NULL.
+func encodeOptionalPort(p *ports.Port) (interface{}, error) {
+	if p == nil {
+		return nil, nil
+	}
+	value, err := json.Marshal(newPortRecord(p, false))
+	if err != nil {
+		return nil, fmt.Errorf("encode port: %w", err)
+	}
+	return value, nil
+}
+
+// inTx calls fn in a transaction, which is committed if fn succeeds.
+func (r *PostgresPortsRepository) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// lockPort selects the port with given ID, including soft-deleted one, and locks it until the transaction ends.
+// It returns nil port if there is no such port, and reports whether the port is soft-deleted.
+func lockPort(ctx context.Context, tx *sql.Tx, id string) (*ports.Port, bool, error) {
+	var deleted bool
+	row := tx.QueryRowContext(
+		ctx, "SELECT "+selectPortColumns+", deleted_at IS NOT NULL FROM ports WHERE id = $1 FOR UPDATE", id,
+	)
+	p, err := scanPort(row, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("select port with ID %v: %w", id, err)
+	}
+	return &p, deleted, nil
+}
+
+// upsertPort inserts given port or replaces the locked port with the same ID, including soft-deleted one, and reports
+// whether the port was inserted or the replaced one was soft-deleted.
+func upsertPort(ctx context.Context, tx *sql.Tx, port *ports.Port, wasDeleted bool) (ports.Port, bool, error) {
+	// xmax is zero for inserted rows
+	var inserted bool
+	row := tx.QueryRowContext(ctx, `
+		INSERT INTO ports (`+portColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			city = EXCLUDED.city,
+			country = EXCLUDED.country,
+			alias = EXCLUDED.alias,
+			regions = EXCLUDED.regions,
+			coordinates = EXCLUDED.coordinates,
+			province = EXCLUDED.province,
+			timezone = EXCLUDED.timezone,
+			unlocs = EXCLUDED.unlocs,
+			code = EXCLUDED.code,
+			search_terms = EXCLUDED.search_terms,
+			deleted_at = NULL,
+			version = ports.version + 1
+		RETURNING `+selectPortColumns+`, xmax = 0`,
+		portArgs(port)...,
+	)
+	stored, err := scanPort(row, &inserted)
+	if err != nil {
+		return ports.Port{}, false, fmt.Errorf("upsert port with ID %v: %w", port.ID, err)
+	}
+	return stored, inserted || wasDeleted, nil
+}
+
+// replacePort replaces the live port with the version of given port and returns the stored port.
+func replacePort(ctx context.Context, tx *sql.Tx, port *ports.Port) (ports.Port, error) {
+	row := tx.QueryRowContext(ctx, `
+		UPDATE ports SET `+updatePortAssignments+`, version = version + 1
+		WHERE id = $1 AND version = $13 AND deleted_at IS NULL
+		RETURNING `+selectPortColumns,
+		append(portArgs(port), port.Version)...,
+	)
+	stored, err := scanPort(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ports.Port{}, fmt.Errorf("store port: %w", ports.NewConflictError(port.ID, port.Version))
+	}
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("update port with ID %v: %w", port.ID, err)
+	}
+	return stored, nil
+}
+
+// claimUnlocs records the Unlocs of given port other than its ID, releasing its previous Unlocs.
+// ports.DuplicateUnlocError is returned if any of the Unlocs is listed by another port.
+func claimUnlocs(ctx context.Context, tx *sql.Tx, port *ports.Port) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM port_unlocs WHERE port_id = $1", port.ID); err != nil {
+		return fmt.Errorf("release codes of port with ID %v: %w", port.ID, err)
+	}
+
+	codes := port.OtherUnlocs()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO port_unlocs (unloc, port_id)
+		SELECT unnest($2::TEXT[]), $1
+		ON CONFLICT (unloc) DO NOTHING`,
+		port.ID, pq.StringArray(codes),
+	)
+	if err != nil {
+		return fmt.Errorf("claim codes of port with ID %v: %w", port.ID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if n == int64(len(codes)) {
+		return nil
+	}
+
+	// The insert waits for concurrent transactions claiming the same codes, so their claims are visible now
+	var code, otherID string
+	err = tx.QueryRowContext(
+		ctx, "SELECT unloc, port_id FROM port_unlocs WHERE unloc = ANY($1) AND port_id <> $2 LIMIT 1",
+		pq.StringArray(codes), port.ID,
+	).Scan(&code, &otherID)
+	if err != nil {
+		return fmt.Errorf("select claimed codes of port with ID %v: %w", port.ID, err)
+	}
+	return ports.NewDuplicateUnlocError(port.ID, code, otherID)
+}
+
+// storeNames replaces the folded name and aliases of given port matched by SearchPorts. They are kept when the port
+// is soft-deleted, as the search skips deleted ports.
+func storeNames(ctx context.Context, tx *sql.Tx, port *ports.Port) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM port_names WHERE port_id = $1", port.ID); err != nil {
+		return fmt.Errorf("delete names of port with ID %v: %w", port.ID, err)
+	}
+
+	names := make([]string, 0, len(port.Alias)+1)
+	for _, name := range append([]string{port.Name}, port.Alias...) {
+		names = append(names, ports.FoldName(name))
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO port_names (folded_name, port_id)
+		SELECT DISTINCT unnest($2::TEXT[]), $1`,
+		port.ID, pq.StringArray(names),
+	)
+	if err != nil {
+		return fmt.Errorf("insert names of port with ID %v: %w", port.ID, err)
+	}
+	return nil
+}
+
+// updatePortAssignments set port values given in portArgs order, except the ID.
+const updatePortAssignments = `
+			name = $2,
+			city = $3,
+			country = $4,
+			alias = $5,
+			regions = $6,
+			coordinates = $7,
+			province = $8,
+			timezone = $9,
+			unlocs = $10,
+			code = $11,
+			search_terms = $12`
+
+// LookupPort returns the port identified by given code or ports.NotFoundError. The port with the code as ID is
+// preferred over the port listing it in Unlocs.
+func (r *PostgresPortsRepository) LookupPort(ctx context.Context, unloc string) (ports.Port, error) {
+	r.log.WithField("unloc", unloc).Debug("Looking up port")
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+selectPortColumns+` FROM ports
+		WHERE id IN ($1, (SELECT port_id FROM port_unlocs WHERE unloc = $1)) AND deleted_at IS NULL
+		ORDER BY id = $1 DESC
+		LIMIT 1`,
+		unloc,
+	)
+
+	p, err := scanPort(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ports.Port{}, fmt.Errorf("look up port: %w", ports.NewNotFoundError(unloc))
+	}
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("look up port with code %v: %w", unloc, err)
+	}
+	return p, nil
+}
+
+// GetPort returns the port with given ID or ports.NotFoundError.
+func (r *PostgresPortsRepository) GetPort(ctx context.Context, id string) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Getting port")
+	row := r.db.QueryRowContext(ctx, "SELECT "+selectPortColumns+" FROM ports WHERE id = $1 AND deleted_at IS NULL", id)
+
+	p, err := scanPort(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ports.Port{}, fmt.Errorf("get port: %w", ports.NewNotFoundError(id))
+	}
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("get port with ID %v: %w", id, err)
+	}
+	return p, nil
+}
+
+// ListPorts lists ports in ascending ID order, starting after the cursor position.
+func (r *PostgresPortsRepository) ListPorts(ctx context.Context, c ports.Cursor) ([]ports.Port, error) {
+	r.log.WithField("cursor", c).Debug("Listing ports")
+	return r.queryPorts(ctx, nil, nil, c)
+}
+
+// SearchPorts lists ports matching the filter in ascending ID order, starting after the cursor position.
+// Only the conditions of filtered fields are added to the query, so that the planner can use their indexes.
+func (r *PostgresPortsRepository) SearchPorts(
+	ctx context.Context, f ports.PortFilter, c ports.Cursor,
+) ([]ports.Port, error) {
+	r.log.WithFields(logrus.Fields{
+		"filter": f,
+		"cursor": c,
+	}).Debug("Searching ports")
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(format string, arg interface{}) {
+		args = append(args, arg)
+		// The cursor takes the first two parameters
+		conditions = append(conditions, fmt.Sprintf(format, len(args)+2))
+	}
+	for _, field := range []struct {
+		column string
+		filter string
+	}{
+		{column: "country", filter: f.Country},
+		{column: "province", filter: f.Province},
+		{column: "timezone", filter: f.Timezone},
+		{column: "code", filter: f.Code},
+	} {
+		if field.filter != "" {
+			addCondition(field.column+" = $%d", field.filter)
+		}
+	}
+	if f.Region != "" {
+		addCondition("regions @> ARRAY[$%d]", f.Region)
+	}
+	if f.NamePrefix != "" {
+		pattern := likeEscaper.Replace(ports.FoldName(f.NamePrefix)) + "%"
+		addCondition("id IN (SELECT port_id FROM port_names WHERE folded_name LIKE $%d)", pattern)
+	}
+
+	return r.queryPorts(ctx, conditions, args, c)
+}
+
+// likeEscaper escapes LIKE pattern wildcards with the default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// queryPorts lists ports matching the conditions in ascending ID order, starting after the cursor position.
+// Condition parameters are numbered from $3, and args are their values.
+func (r *PostgresPortsRepository) queryPorts(
+	ctx context.Context, conditions []string, args []interface{}, c ports.Cursor,
+) ([]ports.Port, error) {
+	limit := sql.NullInt64{Int64: int64(c.Limit), Valid: c.Limit > 0}
+	where := append([]string{"id > $1", "deleted_at IS NULL"}, conditions...)
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+selectPortColumns+" FROM ports WHERE "+strings.Join(where, " AND ")+" ORDER BY id LIMIT $2",
+		append([]interface{}{c.AfterID, limit}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query ports: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]ports.Port, 0, c.Limit)
+	for rows.Next() {
+		p, err := scanPort(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ports: %w", err)
+	}
+	return result, nil
+}
+
+// UpdatePort applies the update function to the port with given ID and stores the result. The port row is locked
+// until the update is stored, so that concurrent updates do not overwrite each other.
+func (r *PostgresPortsRepository) UpdatePort(
+	ctx context.Context, id string, update func(*ports.Port) error,
+) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Updating port")
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	row := tx.QueryRowContext(
+		ctx, "SELECT "+selectPortColumns+" FROM ports WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id,
+	)
+	p, err := scanPort(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ports.Port{}, fmt.Errorf("update port: %w", ports.NewNotFoundError(id))
+	}
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("select port with ID %v: %w", id, err)
+	}
+
+	previous := p
+	version := p.Version
+	if err = update(&p); err != nil {
+		return ports.Port{}, err
+	}
+	p.ID = id
+	p.Version = version + 1
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ports SET `+updatePortAssignments+`, version = $13
+		WHERE id = $1`,
+		append(portArgs(&p), p.Version)...,
+	)
+	if err != nil {
+		return ports.Port{}, fmt.Errorf("update port with ID %v: %w", id, err)
+	}
+	if err = claimUnlocs(ctx, tx, &p); err != nil {
+		return ports.Port{}, err
+	}
+	if err = storeNames(ctx, tx, &p); err != nil {
+		return ports.Port{}, err
+	}
+	if err = r.recordChange(ctx, tx, ports.EventUpdated, &previous, &p); err != nil {
+		return ports.Port{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return ports.Port{}, fmt.Errorf("commit transaction: %w", err)
+	}
+	return p, nil
+}
+
+// DeletePort soft-deletes the port with given ID and returns it with the next version, or returns
+// ports.NotFoundError. The port codes are released, so that other ports can claim them.
+func (r *PostgresPortsRepository) DeletePort(ctx context.Context, id string) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Deleting port")
+	var p ports.Port
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `
+			UPDATE ports SET deleted_at = now(), version = version + 1
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING `+selectPortColumns,
+			id,
+		)
+
+		var err error
+		p, err = scanPort(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("delete port: %w", ports.NewNotFoundError(id))
+		}
+		if err != nil {
+			return fmt.Errorf("soft-delete port with ID %v: %w", id, err)
+		}
+
+		if _, err = tx.ExecContext(ctx, "DELETE FROM port_unlocs WHERE port_id = $1", id); err != nil {
+			return fmt.Errorf("release codes of port with ID %v: %w", id, err)
+		}
+		// The event carries the deleted port, while the history entry keeps the port before the deletion
+		previous := p
+		previous.Version--
+		if err = recordHistory(ctx, tx, ports.EventDeleted, &previous, nil); err != nil {
+			return err
+		}
+		return r.recordEvent(ctx, tx, ports.EventDeleted, &p)
+	})
+	if err != nil {
+		return ports.Port{}, err
+	}
+	return p, nil
+}
+
+// RestorePort restores the soft-deleted port with given ID and returns it with the next version, or returns
+// ports.NotFoundError. The port codes are claimed again, unless another port claimed them in the meantime.
+func (r *PostgresPortsRepository) RestorePort(ctx context.Context, id string) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Restoring port")
+	var p ports.Port
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `
+			UPDATE ports SET deleted_at = NULL, version = version + 1
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			RETURNING `+selectPortColumns,
+			id,
+		)
+
+		var err error
+		p, err = scanPort(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("restore deleted port: %w", ports.NewNotFoundError(id))
+		}
+		if err != nil {
+			return fmt.Errorf("restore port with ID %v: %w", id, err)
+		}
+		if err = claimUnlocs(ctx, tx, &p); err != nil {
+			return err
+		}
+		previous := p
+		previous.Version--
+		return r.recordChange(ctx, tx, ports.EventCreated, &previous, &p)
+	})
+	if err != nil {
+		return ports.Port{}, err
+	}
+	return p, nil
+}
+
+// PurgePort permanently removes the port with given ID and returns it, or returns ports.NotFoundError.
+func (r *PostgresPortsRepository) PurgePort(ctx context.Context, id string) (ports.Port, error) {
+	r.log.WithField("port-id", id).Debug("Purging port")
+	var p ports.Port
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, "DELETE FROM ports WHERE id = $1 RETURNING "+selectPortColumns, id)
+
+		var err error
+		p, err = scanPort(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("purge port: %w", ports.NewNotFoundError(id))
+		}
+		if err != nil {
+			return fmt.Errorf("delete port with ID %v: %w", id, err)
+		}
+		return r.recordChange(ctx, tx, ports.EventDeleted, &p, nil)
+	})
+	if err != nil {
+		return ports.Port{}, err
+	}
+	return p, nil
+}
+
+// GetPortHistory lists history entries of the port with given ID, starting after the cursor sequence.
+func (r *PostgresPortsRepository) GetPortHistory(
+	ctx context.Context, id string, c ports.HistoryCursor,
+) ([]ports.HistoryEntry, error) {
+	r.log.WithFields(logrus.Fields{
+		"port-id": id,
+		"cursor":  c,
+	}).Debug("Getting port history")
+
+	limit := sql.NullInt64{Int64: int64(c.Limit), Valid: c.Limit > 0}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT sequence, type, previous, port, changed_at, caller FROM port_history
+		WHERE port_id = $1 AND sequence > $2
+		ORDER BY sequence LIMIT $3`,
+		id, c.AfterSequence, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query port history: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]ports.HistoryEntry, 0, c.Limit)
+	for rows.Next() {
+		var (
+			rec            historyRecord
+			previous, port []byte
+		)
+		if err = rows.Scan(&rec.Sequence, &rec.Type, &previous, &port, &rec.Time, &rec.Caller); err != nil {
+			return nil, fmt.Errorf("scan history entry: %w", err)
+		}
+		if rec.Previous, err = decodeOptionalPort(previous); err != nil {
+			return nil, fmt.Errorf("decode previous port of history entry %v: %w", rec.Sequence, err)
+		}
+		if rec.Port, err = decodeOptionalPort(port); err != nil {
+			return nil, fmt.Errorf("decode port of history entry %v: %w", rec.Sequence, err)
+		}
+		e, err := rec.toDomain()
+		if err != nil {
+			return nil, fmt.Errorf("decode history entry %v: %w", rec.Sequence, err)
+		}
+		result = append(result, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate port history: %w", err)
+	}
+	if len(result) > 0 {
+		return result, nil
+	}
+
+	var recorded bool
+	err = r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT FROM port_history WHERE port_id = $1)", id).Scan(&recorded)
+	if err != nil {
+		return nil, fmt.Errorf("check port history: %w", err)
+	}
+	if !recorded {
+		return nil, fmt.Errorf("get port history: %w", ports.NewNotFoundError(id))
+	}
+	return result, nil
+}
+
+func decodeOptionalPort(value []byte) (*portRecord, error) {
+	if value == nil {
+		return nil, nil
+	}
+	var rec portRecord
+	if err := json.Unmarshal(value, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// PendingPortChanges returns up to limit oldest events of the outbox. Sequences are assigned before the changes
+// are committed, so an event of a concurrent change can be returned after the events with greater sequences.
+// Events of the same port are returned in order, as its row is locked by the changes.
+func (r *PostgresPortsRepository) PendingPortChanges(ctx context.Context, limit int) ([]ports.PortChanged, error) {
+	rows, err := r.db.QueryContext(
+		ctx, "SELECT sequence, type, port, created_at FROM port_changes ORDER BY sequence LIMIT $1", limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select port changes: %w", err)
+	}
+	defer rows.Close()
+
+	var result []ports.PortChanged
+	for rows.Next() {
+		var (
+			rec  portChangeRecord
+			port []byte
+		)
+		if err = rows.Scan(&rec.Sequence, &rec.Type, &port, &rec.Time); err != nil {
+			return nil, fmt.Errorf("scan port change: %w", err)
+		}
+		if err = json.Unmarshal(port, &rec.Port); err != nil {
+			return nil, fmt.Errorf("decode port of change %v: %w", rec.Sequence, err)
+		}
+		e, err := rec.toDomain()
+		if err != nil {
+			return nil, fmt.Errorf("decode port change %v: %w", rec.Sequence, err)
+		}
+		result = append(result, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate port changes: %w", err)
+	}
+	return result, nil
+}
+
+// AckPortChanges removes the events with given sequences from the outbox.
+func (r *PostgresPortsRepository) AckPortChanges(ctx context.Context, sequences []int64) error {
+	r.log.WithField("count", len(sequences)).Debug("Acknowledging port changes")
+	_, err := r.db.ExecContext(ctx, "DELETE FROM port_changes WHERE sequence = ANY($1)", pq.Int64Array(sequences))
+	if err != nil {
+		return fmt.Errorf("delete port changes: %w", err)
+	}
+	return nil
+}
+
+// SuggestPorts finds ports matching the text query. Candidate ports with words similar to all query terms are found
+// with the trigram index of search terms, and then scored by the query matcher. Terms with many typos can be
+// dissimilar in trigrams, so unlike in-memory repository, their ports can be missed.
+func (r *PostgresPortsRepository) SuggestPorts(ctx context.Context, q ports.TextQuery) ([]ports.ScoredPort, error) {
+	r.log.WithField("query", q).Debug("Suggesting ports")
+	m := q.Matcher()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// The threshold of <% operator is a setting, so that the operator can use the index
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %v", suggestWordSimilarity))
+	if err != nil {
+		return nil, fmt.Errorf("set word similarity threshold: %w", err)
+	}
+
+	conditions := make([]string, 0, len(m.Terms))
+	similarities := make([]string, 0, len(m.Terms))
+	args := make([]interface{}, 0, len(m.Terms)+1)
+	for i, term := range m.Terms {
+		args = append(args, term)
+		conditions = append(conditions, fmt.Sprintf("$%d <%% search_terms", i+1))
+		similarities = append(similarities, fmt.Sprintf("word_similarity($%d, search_terms)", i+1))
+	}
+	args = append(args, q.Limit*suggestCandidatesFactor)
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT `+selectPortColumns+` FROM ports
+		WHERE deleted_at IS NULL AND %v
+		ORDER BY %v DESC, id
+		LIMIT $%d`,
+		strings.Join(conditions, " AND "), strings.Join(similarities, " + "), len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query suggested ports: %w", err)
+	}
+	defer rows.Close()
+
+	var result []ports.ScoredPort
+	for rows.Next() {
+		p, err := scanPort(rows)
+		if err != nil {
+			return nil, err
+		}
+		if score, ok := m.Score(p); ok {
+			result = append(result, ports.ScoredPort{
+				Port:  p,
+				Score: score,
+			})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate suggested ports: %w", err)
+	}
+
+	ports.SortScoredPorts(result)
+	if len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result, nil
+}
+
+// FindNearbyPorts finds ports nearest to the query location. Distances are calculated with the haversine formula.
+// The ports are searched in latitude bands narrowed with the latitude index. The band grows until it contains
+// enough ports within its radius, or reaches the query radius.
+func (r *PostgresPortsRepository) FindNearbyPorts(ctx context.Context, q ports.NearbyQuery) ([]ports.NearbyPort, error) {
+	r.log.WithField("query", q).Debug("Finding nearby ports")
+	maxRadiusKm := q.RadiusKm
+	if maxRadiusKm <= 0 {
+		maxRadiusKm = math.Pi * ports.EarthRadiusKm
+	}
+
+	radiusKm := math.Min(nearbyInitialRadiusKm, maxRadiusKm)
+	for {
+		result, err := r.findPortsWithin(ctx, *q.Location, radiusKm, q.Limit)
+		if err != nil {
+			return nil, err
+		}
+		// Ports that were not found are farther than the radius, so the found ones are the nearest
+		if len(result) == q.Limit || radiusKm >= maxRadiusKm {
+			return result, nil
+		}
+		radiusKm = math.Min(radiusKm*nearbyRadiusGrowth, maxRadiusKm)
+	}
+}
+
+// findPortsWithin finds up to limit ports nearest to the location within the radius.
+func (r *PostgresPortsRepository) findPortsWithin(
+	ctx context.Context, location ports.LatLng, radiusKm float64, limit int,
+) ([]ports.NearbyPort, error) {
+	latDelta := radiusKm / ports.EarthRadiusKm * 180 / math.Pi
+	minLat, maxLat := location.Lat-latDelta, location.Lat+latDelta
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT * FROM (
+			SELECT `+selectPortColumns+`, 2 * $1 * asin(sqrt(least(1,
+				power(sin(radians(coordinates[2] - $2) / 2), 2) +
+				cos(radians($2)) * cos(radians(coordinates[2])) * power(sin(radians(coordinates[1] - $3) / 2), 2)
+			))) AS distance_km
+			FROM ports
+			WHERE deleted_at IS NULL AND cardinality(coordinates) = 2 AND coordinates[2] BETWEEN $4 AND $5
+		) AS nearby
+		WHERE distance_km <= $6
+		ORDER BY distance_km, id
+		LIMIT $7`,
+		ports.EarthRadiusKm, location.Lat, location.Lng, minLat, maxLat, radiusKm, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query nearby ports: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]ports.NearbyPort, 0, limit)
+	for rows.Next() {
+		var distanceKm float64
+		p, err := scanPort(rows, &distanceKm)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ports.NearbyPort{
+			Port:       p,
+			DistanceKm: distanceKm,
+		})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate nearby ports: %w", err)
+	}
+	return result, nil
+}
+
+// Close closes the DB connection pool.
+func (r *PostgresPortsRepository) Close() error {
+	return r.db.Close()
+}
+
+// portArgs returns query arguments with port values in portColumns order.
+func portArgs(p *ports.Port) []interface{} {
+	return []interface{}{
+		p.ID,
+		p.Name,
+		p.City,
+		p.Country,
+		pq.StringArray(p.Alias),
+		pq.StringArray(p.Regions),
+		pq.Float64Array(p.Location.Coordinates()),
+		p.Province,
+		p.Timezone,
+		pq.StringArray(p.Unlocs),
+		p.Code,
+		searchTerms(*p),
+	}
+}
+
+// searchTerms returns the port terms separated with spaces, so that they are words for trigram matching.
+func searchTerms(p ports.Port) string {
+	return strings.Join(ports.PortTerms(p), " ")
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPort scans selectPortColumns, followed by given extra columns.
+func scanPort(row rowScanner, extra ...interface{}) (ports.Port, error) {
+	var (
+		p                      ports.Port
+		alias, regions, unlocs pq.StringArray
+		coordinates            pq.Float64Array
+	)
+	dest := append([]interface{}{
+		&p.ID,
+		&p.Name,
+		&p.City,
+		&p.Country,
+		&alias,
+		&regions,
+		&coordinates,
+		&p.Province,
+		&p.Timezone,
+		&unlocs,
+		&p.Code,
+		&p.Version,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return ports.Port{}, err
+	}
+
+	p.Alias = nilIfEmpty(alias)
+	p.Regions = nilIfEmpty(regions)
+	// Stored ports are validated, so coordinates are either empty or a pair
+	p.Location, _ = ports.LatLngFromCoordinates(coordinates)
+	p.Unlocs = nilIfEmpty(unlocs)
+	return p, nil
+}
+
+// nilIfEmpty normalizes empty arrays read from DB, so that ports stored with nil and empty slices are read alike.
+func nilIfEmpty[T any](s []T) []T {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package adapter_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/adapter"
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postgresTestURLEnv is a name of env var with PostgreSQL connection URL used by tests.
+// Tests that require PostgreSQL are skipped if it is not set.
+const postgresTestURLEnv = "POSTGRES_TEST_URL"
+
+func TestPostgresPortsRepository(t *testing.T) {
+	ctx := context.Background()
+	url := postgresTestURL(t)
+
+	// Migrations are applied only once
+	repo, err := adapter.NewPostgresPortsRepository(ctx, url)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	repo, err = adapter.NewPostgresPortsRepository(ctx, url)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, repo.Close())
+	}()
+
+	// Store and get
+	ajman := newAjmanPort()
+	stored, outcome, err := repo.StorePort(ctx, ajman)
+	require.NoError(t, err)
+	assert.Equal(t, ports.StoreCreated, outcome)
+
+	p, err := repo.GetPort(ctx, ajman.ID)
+	require.NoError(t, err)
+	ajman.Version = 1
+	assert.Equal(t, *ajman, p)
+	assert.Equal(t, p, stored)
+
+	_, err = repo.GetPort(ctx, "AEDXB")
+	assert.ErrorIs(t, err, ports.ErrPortNotFound)
+
+	// Upsert
+	ajman.Name = "Ajman Port"
+	ajman.Alias = nil
+	stored, outcome, err = repo.StorePort(ctx, ajman)
+	require.NoError(t, err)
+	assert.Equal(t, ports.StoreUpdated, outcome)
+
+	p, err = repo.GetPort(ctx, ajman.ID)
+	require.NoError(t, err)
+	ajman.Version = 2
+	assert.Equal(t, *ajman, p)
+	assert.Equal(t, p, stored)
+
+	// Store unchanged
+	stored, outcome, err = repo.StorePort(ctx, ajman)
+	require.NoError(t, err)
+	assert.Equal(t, ports.StoreUnchanged, outcome)
+	assert.Equal(t, p, stored)
+	history, err := repo.GetPortHistory(ctx, ajman.ID, ports.HistoryCursor{})
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	// Stale version
+	ajman.Version = 1
+	_, _, err = repo.StorePort(ctx, ajman)
+	assert.ErrorIs(t, err, ports.ErrVersionMismatch)
+
+	// Delete and restore increment the version
+	p, err = repo.DeletePort(ctx, ajman.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), p.Version)
+	p, err = repo.RestorePort(ctx, ajman.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), p.Version)
+	ajman.Version = 2
+	_, _, err = repo.StorePort(ctx, ajman)
+	assert.ErrorIs(t, err, ports.ErrVersionMismatch, "version before the deletion is stale")
+	ajman.Version = 4
+
+	// List with cursor
+	for _, id := range []string{"AEDXB", "AEAUH"} {
+		other := newAjmanPort()
+		other.ID = id
+		other.Unlocs = []string{id}
+		storePort(t, repo, other)
+	}
+
+	ps, err := repo.ListPorts(ctx, ports.Cursor{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AEAJM", "AEAUH"}, portIDs(ps))
+
+	ps, err = repo.ListPorts(ctx, ports.Cursor{AfterID: "AEAUH"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AEDXB"}, portIDs(ps))
+}
+
+// postgresTestURL returns the URL of the test database after cleaning it up. It skips the test if the URL is not set.
+func postgresTestURL(t *testing.T) string {
+	url := os.Getenv(postgresTestURLEnv)
+	if url == "" {
+		t.Skipf("%v env var is not set", postgresTestURLEnv)
+	}
+
+	db, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS port_history, port_changes, port_names, port_unlocs, ports, schema_migrations")
+	require.NoError(t, err)
+	return url
+}
+
+func portIDs(ps []ports.Port) []string {
+	ids := make([]string, 0, len(ps))
+	for _, p := range ps {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func newAjmanPort() *ports.Port {
+	return &ports.Port{
+		ID:       "AEAJM",
+		Name:     "Ajman",
+		City:     "Ajman",
+		Country:  "United Arab Emirates",
+		Alias:    []string{"foo-alias", "bar-alias"},
+		Regions:  []string{"foo-region", "bar-region"},
+		Location: &ports.LatLng{Lat: 25.4052165, Lng: 55.5136433},
+		Province: "Ajman",
+		Timezone: "Asia/Dubai",
+		Unlocs:   []string{"AEAJM"},
+		Code:     "52000",
+	}
+}
@@ -0,0 +1,303 @@
+package adapter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+)
+
+// idSet is a set of port IDs.
+type idSet map[string]struct{}
+
+// valueIndex maps field values to IDs of the ports with that value. Empty values are not indexed.
+type valueIndex map[string]idSet
+
+func (x valueIndex) add(value, id string) {
+	if value == "" {
+		return
+	}
+	if x[value] == nil {
+		x[value] = make(idSet)
+	}
+	x[value][id] = struct{}{}
+}
+
+func (x valueIndex) remove(value, id string) {
+	delete(x[value], id)
+	if len(x[value]) == 0 {
+		delete(x, value)
+	}
+}
+
+// prefixIndex maps keys to IDs of the ports with that key, and keeps the keys in ascending order unless
+// sortedKeysStale is set, so that keys with given prefix form a continuous range.
+type prefixIndex struct {
+	ids valueIndex
+	// sortedKeys contains the keys of ids. When stale, it can also contain duplicates and keys removed from ids.
+	sortedKeys      []string
+	sortedKeysStale bool
+}
+
+func newPrefixIndex() *prefixIndex {
+	return &prefixIndex{ids: make(valueIndex)}
+}
+
+func (x *prefixIndex) add(key, id string) {
+	if key == "" {
+		return
+	}
+	if _, ok := x.ids[key]; !ok {
+		if n := len(x.sortedKeys); n > 0 && x.sortedKeys[n-1] >= key {
+			x.sortedKeysStale = true
+		}
+		x.sortedKeys = append(x.sortedKeys, key)
+	}
+	x.ids.add(key, id)
+}
+
+// remove removes the ID from the key. Keys without IDs are removed from sortedKeys on the next sort, so that
+// removing a key does not need to search for it.
+func (x *prefixIndex) remove(key, id string) {
+	if _, ok := x.ids[key]; !ok {
+		return
+	}
+	x.ids.remove(key, id)
+	if _, ok := x.ids[key]; !ok {
+		x.sortedKeysStale = true
+	}
+}
+
+func (x *prefixIndex) sort() {
+	sort.Strings(x.sortedKeys)
+	keys := x.sortedKeys[:0]
+	for i, key := range x.sortedKeys {
+		if _, ok := x.ids[key]; ok && (i == 0 || key != x.sortedKeys[i-1]) {
+			keys = append(keys, key)
+		}
+	}
+	x.sortedKeys = keys
+	x.sortedKeysStale = false
+}
+
+// match returns IDs of the ports with a key starting with the prefix. Keys must be sorted.
+func (x *prefixIndex) match(prefix string) idSet {
+	result := make(idSet)
+	for i := sort.SearchStrings(x.sortedKeys, prefix); i < len(x.sortedKeys); i++ {
+		if !strings.HasPrefix(x.sortedKeys[i], prefix) {
+			break
+		}
+		addIDs(result, x.ids[x.sortedKeys[i]])
+	}
+	return result
+}
+
+func addIDs(dst, src idSet) {
+	for id := range src {
+		dst[id] = struct{}{}
+	}
+}
+
+// searchIndex is a secondary index of port fields, so that searching ports does not need to check all of them.
+// It is not safe for concurrent use.
+type searchIndex struct {
+	country  valueIndex
+	province valueIndex
+	region   valueIndex
+	timezone valueIndex
+	code     valueIndex
+	// names maps case-folded names and aliases to IDs.
+	names *prefixIndex
+	// terms maps terms of port text fields to IDs.
+	terms *prefixIndex
+	// termGrams maps trigrams of the terms to the terms, to find the terms with typos.
+	termGrams valueIndex
+	// indexed contains the indexed ports, so that their values can be removed when they change.
+	// Indexed ports are never modified in place.
+	indexed map[string]*ports.Port
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		country:   make(valueIndex),
+		province:  make(valueIndex),
+		region:    make(valueIndex),
+		timezone:  make(valueIndex),
+		code:      make(valueIndex),
+		names:     newPrefixIndex(),
+		terms:     newPrefixIndex(),
+		termGrams: make(valueIndex),
+		indexed:   make(map[string]*ports.Port),
+	}
+}
+
+// put indexes the port, replacing previously indexed values of the port with the same ID.
+func (x *searchIndex) put(p *ports.Port) {
+	x.remove(p.ID)
+
+	x.country.add(p.Country, p.ID)
+	x.province.add(p.Province, p.ID)
+	x.timezone.add(p.Timezone, p.ID)
+	x.code.add(p.Code, p.ID)
+	for _, region := range p.Regions {
+		x.region.add(region, p.ID)
+	}
+	x.names.add(ports.FoldName(p.Name), p.ID)
+	for _, alias := range p.Alias {
+		x.names.add(ports.FoldName(alias), p.ID)
+	}
+	for _, term := range ports.PortTerms(*p) {
+		if _, ok := x.terms.ids[term]; !ok {
+			for _, gram := range termGrams(term) {
+				x.termGrams.add(gram, term)
+			}
+		}
+		x.terms.add(term, p.ID)
+	}
+	x.indexed[p.ID] = p
+}
+
+func (x *searchIndex) remove(id string) {
+	p, ok := x.indexed[id]
+	if !ok {
+		return
+	}
+
+	x.country.remove(p.Country, id)
+	x.province.remove(p.Province, id)
+	x.timezone.remove(p.Timezone, id)
+	x.code.remove(p.Code, id)
+	for _, region := range p.Regions {
+		x.region.remove(region, id)
+	}
+	x.names.remove(ports.FoldName(p.Name), id)
+	for _, alias := range p.Alias {
+		x.names.remove(ports.FoldName(alias), id)
+	}
+	for _, term := range ports.PortTerms(*p) {
+		x.terms.remove(term, id)
+		if _, ok := x.terms.ids[term]; !ok {
+			for _, gram := range termGrams(term) {
+				x.termGrams.remove(gram, term)
+			}
+		}
+	}
+	delete(x.indexed, id)
+}
+
+// isStale reports whether sort needs to be called before finding candidates.
+func (x *searchIndex) isStale() bool {
+	return x.names.sortedKeysStale || x.terms.sortedKeysStale
+}
+
+func (x *searchIndex) sort() {
+	x.names.sort()
+	x.terms.sort()
+}
+
+// candidates returns IDs of the ports that can match the filter, found with the most selective indexed filter field.
+// The name prefix is used only if no other field is filtered, as matching it is the most expensive.
+// Returned ports still need to be checked against the whole filter. If ok is false, all ports can match the filter.
+func (x *searchIndex) candidates(f ports.PortFilter) (ids idSet, ok bool) {
+	for _, field := range []struct {
+		index  valueIndex
+		filter string
+	}{
+		{index: x.country, filter: f.Country},
+		{index: x.province, filter: f.Province},
+		{index: x.region, filter: f.Region},
+		{index: x.timezone, filter: f.Timezone},
+		{index: x.code, filter: f.Code},
+	} {
+		if field.filter == "" {
+			continue
+		}
+		if matching := field.index[field.filter]; !ok || len(matching) < len(ids) {
+			ids, ok = matching, true
+		}
+	}
+
+	if !ok && f.NamePrefix != "" {
+		return x.names.match(ports.FoldName(f.NamePrefix)), true
+	}
+	return ids, ok
+}
+
+// textCandidates returns IDs of the ports with terms that can match all terms of the matcher.
+// Returned ports still need to be scored by the matcher.
+func (x *searchIndex) textCandidates(m ports.TextMatcher) idSet {
+	var result idSet
+	for i, queryTerm := range m.Terms {
+		ids := make(idSet)
+		addIDs(ids, x.terms.ids[queryTerm])
+		if m.IsPrefix(i) {
+			addIDs(ids, x.terms.match(queryTerm))
+		}
+		x.similarTerms(queryTerm, func(term string) {
+			addIDs(ids, x.terms.ids[term])
+		})
+
+		if result == nil {
+			result = ids
+			continue
+		}
+		for id := range result {
+			if _, ok := ids[id]; !ok {
+				delete(result, id)
+			}
+		}
+	}
+	return result
+}
+
+// similarTerms calls fn with the indexed terms within ports.MaxTermEdits of the query term.
+// A typo changes at most 4 trigrams of the term, so the terms sharing too few trigrams with the query term
+// are skipped without calculating the edit distance.
+func (x *searchIndex) similarTerms(queryTerm string, fn func(term string)) {
+	maxEdits := ports.MaxTermEdits(queryTerm)
+	if maxEdits == 0 {
+		return
+	}
+	isSimilar := func(term string) bool {
+		return term != queryTerm && ports.EditDistance(queryTerm, term) <= maxEdits
+	}
+
+	grams := termGrams(queryTerm)
+	minSharedGrams := len(grams) - 4*maxEdits
+	if minSharedGrams <= 0 {
+		for term := range x.terms.ids {
+			if isSimilar(term) {
+				fn(term)
+			}
+		}
+		return
+	}
+
+	sharedGrams := make(map[string]int)
+	for _, gram := range grams {
+		for term := range x.termGrams[gram] {
+			sharedGrams[term]++
+		}
+	}
+	for term, shared := range sharedGrams {
+		if shared >= minSharedGrams && isSimilar(term) {
+			fn(term)
+		}
+	}
+}
+
+// termGrams returns the distinct trigrams of the term padded with two spaces on both sides,
+// so that every letter is in three trigrams.
+func termGrams(term string) []string {
+	padded := []rune("  " + term + "  ")
+	seen := make(map[string]struct{}, len(padded))
+	grams := make([]string, 0, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		gram := string(padded[i : i+3])
+		if _, ok := seen[gram]; !ok {
+			seen[gram] = struct{}{}
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
@@ -0,0 +1,54 @@
+package adapter
+
+import "github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+
+// unlocIndex maps Unlocs of ports, other than their IDs, to the port IDs. Port IDs are not indexed, as the port
+// with the ID is preferred over the port listing it in Unlocs. It is not safe for concurrent use.
+type unlocIndex struct {
+	ids map[string]string
+	// unlocs contains the indexed Unlocs of each port, so that they can be removed when the port changes.
+	unlocs map[string][]string
+}
+
+func newUnlocIndex() *unlocIndex {
+	return &unlocIndex{
+		ids:    make(map[string]string),
+		unlocs: make(map[string][]string),
+	}
+}
+
+// put indexes the port Unlocs, replacing previously indexed Unlocs of the port with the same ID.
+// Unlocs of other ports are overwritten, as duplicates recorded before the Unlocs were unique are tolerated.
+func (x *unlocIndex) put(p *ports.Port) {
+	x.remove(p.ID)
+	unlocs := p.OtherUnlocs()
+	for _, unloc := range unlocs {
+		x.ids[unloc] = p.ID
+	}
+	x.unlocs[p.ID] = unlocs
+}
+
+func (x *unlocIndex) remove(id string) {
+	for _, unloc := range x.unlocs[id] {
+		if x.ids[unloc] == id {
+			delete(x.ids, unloc)
+		}
+	}
+	delete(x.unlocs, id)
+}
+
+// lookup returns the ID of the port listing the code in Unlocs.
+func (x *unlocIndex) lookup(code string) (string, bool) {
+	id, ok := x.ids[code]
+	return id, ok
+}
+
+// checkUnique returns ports.DuplicateUnlocError if any Unloc of the port is listed by another port.
+func (x *unlocIndex) checkUnique(p *ports.Port) error {
+	for _, unloc := range p.OtherUnlocs() {
+		if otherID, ok := x.ids[unloc]; ok && otherID != p.ID {
+			return ports.NewDuplicateUnlocError(p.ID, unloc, otherID)
+		}
+	}
+	return nil
+}
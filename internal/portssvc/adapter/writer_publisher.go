@@ -0,0 +1,69 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+)
+
+// WriterPublisher publishes PortChanged events as JSON lines to a writer, e.g. a file or the standard output,
+// so that the events can be consumed without a message broker. It is safe for concurrent use.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+	// file is the file opened by NewFilePublisher, nil otherwise.
+	file *os.File
+}
+
+// NewWriterPublisher creates a publisher writing to given writer.
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// NewFilePublisher creates a publisher appending to the file at given path. The file is created if it does not exist.
+// WriterPublisher.Close() should be called when publisher is no longer needed.
+func NewFilePublisher(path string) (*WriterPublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open publisher file: %w", err)
+	}
+	return &WriterPublisher{
+		w:    file,
+		file: file,
+	}, nil
+}
+
+// PublishPortChanged writes the event as a single line. Events written to a file are synced to disk, so that they
+// are not lost after they are removed from the outbox.
+func (p *WriterPublisher) PublishPortChanged(_ context.Context, e ports.PortChanged) error {
+	line, err := json.Marshal(newPortChangeRecord(e))
+	if err != nil {
+		return fmt.Errorf("encode port change: %w", err)
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err = p.w.Write(line); err != nil {
+		return fmt.Errorf("write port change: %w", err)
+	}
+	if p.file != nil {
+		if err = p.file.Sync(); err != nil {
+			return fmt.Errorf("sync publisher file: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the file opened by NewFilePublisher. It is a no-op for other publishers.
+func (p *WriterPublisher) Close() error {
+	if p.file == nil {
+		return nil
+	}
+	return p.file.Close()
+}
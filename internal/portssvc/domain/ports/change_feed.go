@@ -0,0 +1,225 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ChangeHistorySize is a number of the latest Port events kept by Service, so that watchers can resume after them.
+const ChangeHistorySize = 10000
+
+// EventType is a type of the Port change.
+type EventType int
+
+// Port event types.
+const (
+	// EventCreated is emitted when the Port is stored with an ID of no Port, or of a deleted one, or when the Port
+	// is restored.
+	EventCreated EventType = iota + 1
+	// EventUpdated is emitted when the stored Port is replaced or updated.
+	EventUpdated
+	// EventDeleted is emitted when the Port is soft-deleted or purged.
+	EventDeleted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventCreated:
+		return "created"
+	case EventUpdated:
+		return "updated"
+	case EventDeleted:
+		return "deleted"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// PortEvent is a change of the Port.
+type PortEvent struct {
+	// Revision identifies the event. Revisions of later events are greater.
+	Revision int64
+	Type     EventType
+	// Port is the Port after the change. Deleted Port is the last stored one.
+	Port Port
+}
+
+// WatchQuery is a query for Port events.
+type WatchQuery struct {
+	// AfterRevision is a revision of the last event received before reconnecting. Only the events after it are
+	// watched. Zero AfterRevision watches the events after the current one.
+	AfterRevision int64
+}
+
+// changeFeed keeps the latest Port events in a ring buffer and notifies watchers about new ones.
+// Revisions start at the feed creation time in microseconds, so that revisions of the restarted service are greater
+// than the ones before the restart, and resuming from the latter fails with ErrRevisionCompacted instead of skipping
+// the changes made in the meantime.
+// It is safe for concurrent use.
+type changeFeed struct {
+	mu sync.Mutex
+	// events is a ring buffer of the latest events. The oldest event is at index start once the buffer is full.
+	events []PortEvent
+	start  int
+	// lastRevision is the revision of the latest event, or the revision before the first event.
+	lastRevision int64
+	// published is closed and replaced on every published event.
+	published chan struct{}
+}
+
+func newChangeFeed(size int) *changeFeed {
+	return &changeFeed{
+		events:       make([]PortEvent, 0, size),
+		lastRevision: time.Now().UnixMicro(),
+		published:    make(chan struct{}),
+	}
+}
+
+// publish records the event with the next revision and notifies the watchers.
+func (f *changeFeed) publish(t EventType, p Port) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastRevision++
+	e := PortEvent{
+		Revision: f.lastRevision,
+		Type:     t,
+		Port:     p,
+	}
+	if len(f.events) < cap(f.events) {
+		f.events = append(f.events, e)
+	} else {
+		f.events[f.start] = e
+		f.start = (f.start + 1) % len(f.events)
+	}
+
+	close(f.published)
+	f.published = make(chan struct{})
+}
+
+// revision returns the revision of the latest event.
+func (f *changeFeed) revision() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastRevision
+}
+
+// next returns the channel closed when the next event is published.
+func (f *changeFeed) next() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.published
+}
+
+// eventsAfter returns the events after the revision, and the channel closed when the next event is published.
+// RevisionCompactedError is returned if some events after the revision are not kept anymore.
+func (f *changeFeed) eventsAfter(revision int64) ([]PortEvent, <-chan struct{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if revision > f.lastRevision {
+		return nil, nil, newValidationError(ErrInvalidWatchQuery, FieldViolation{
+			Field:       "after_revision",
+			Description: fmt.Sprintf("revision %v is after the latest revision %v", revision, f.lastRevision),
+		})
+	}
+	if revision == f.lastRevision {
+		return nil, f.published, nil
+	}
+
+	oldestRevision := f.lastRevision - int64(len(f.events)) + 1
+	if revision < oldestRevision-1 {
+		return nil, nil, NewRevisionCompactedError(revision, oldestRevision)
+	}
+
+	// Events are kept in revision order, starting from the oldest at index start
+	skip := int(revision - oldestRevision + 1)
+	result := make([]PortEvent, 0, len(f.events)-skip)
+	for i := skip; i < len(f.events); i++ {
+		result = append(result, f.events[(f.start+i)%len(f.events)])
+	}
+	return result, f.published, nil
+}
+
+// watch calls send for the events after the revision, waiting for the new ones until the context is done or send
+// fails.
+func (f *changeFeed) watch(ctx context.Context, q WatchQuery, send func(PortEvent) error) error {
+	revision := q.AfterRevision
+	if revision < 0 {
+		return newValidationError(ErrInvalidWatchQuery, FieldViolation{
+			Field:       "after_revision",
+			Description: fmt.Sprintf("revision %v must not be negative", revision),
+		})
+	}
+	if revision == 0 {
+		revision = f.revision()
+	}
+
+	for {
+		events, published, err := f.eventsAfter(revision)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			if err = send(e); err != nil {
+				return err
+			}
+			revision = e.Revision
+		}
+
+		if len(events) == 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-published:
+			}
+		}
+	}
+}
+
+// portLocks serializes changes of the same Port, so that their events are published in the order the changes
+// were stored. It is safe for concurrent use.
+type portLocks struct {
+	mu    sync.Mutex
+	locks map[string]*portLock
+}
+
+// portLock is a lock of the Port, removed from portLocks when no change waits for it.
+type portLock struct {
+	mu      sync.Mutex
+	waiters int
+}
+
+func newPortLocks() *portLocks {
+	return &portLocks{locks: make(map[string]*portLock)}
+}
+
+// lock locks the Port with given ID and returns the function that unlocks it.
+func (l *portLocks) lock(id string) (unlock func()) {
+	l.mu.Lock()
+	pl, ok := l.locks[id]
+	if !ok {
+		pl = &portLock{}
+		l.locks[id] = pl
+	}
+	pl.waiters++
+	l.mu.Unlock()
+
+	pl.mu.Lock()
+	return func() {
+		pl.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		pl.waiters--
+		if pl.waiters == 0 {
+			delete(l.locks, id)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors allow to check the kind of typed errors with errors.Is.
+var (
+	// ErrPortNotFound is matched by NotFoundError.
+	ErrPortNotFound = errors.New("port not found")
+	// ErrVersionMismatch is matched by ConflictError.
+	ErrVersionMismatch = errors.New("port version mismatch")
+	// ErrDuplicateUnloc is matched by DuplicateUnlocError.
+	ErrDuplicateUnloc = errors.New("duplicate port UN/LOCODE")
+	// ErrInvalidPort is matched by ValidationError of the Port to store.
+	ErrInvalidPort = errors.New("invalid port")
+	// ErrInvalidUpdate is matched by ValidationError of the Port update paths.
+	ErrInvalidUpdate = errors.New("invalid port update")
+	// ErrInvalidListQuery is matched by ValidationError of the list query.
+	ErrInvalidListQuery = errors.New("invalid list query")
+	// ErrInvalidNearbyQuery is matched by ValidationError of the nearby ports query.
+	ErrInvalidNearbyQuery = errors.New("invalid nearby ports query")
+	// ErrInvalidTextQuery is matched by ValidationError of the text query.
+	ErrInvalidTextQuery = errors.New("invalid text query")
+	// ErrInvalidWatchQuery is matched by ValidationError of the watch query.
+	ErrInvalidWatchQuery = errors.New("invalid watch query")
+	// ErrRevisionCompacted is matched by RevisionCompactedError.
+	ErrRevisionCompacted = errors.New("port events revision compacted")
+)
+
+// ValidationError is returned when the input of the Service is not valid.
+type ValidationError struct {
+	// Kind is ErrInvalidPort, ErrInvalidUpdate, ErrInvalidListQuery, ErrInvalidNearbyQuery, ErrInvalidTextQuery
+	// or ErrInvalidWatchQuery.
+	Kind       error
+	Violations []FieldViolation
+}
+
+// FieldViolation describes why the field is not valid. The field is identified by its snake_case API name.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+func newValidationError(kind error, violations ...FieldViolation) *ValidationError {
+	return &ValidationError{
+		Kind:       kind,
+		Violations: violations,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	descriptions := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		descriptions = append(descriptions, v.Field+": "+v.Description)
+	}
+	return fmt.Sprintf("%v: %v", e.Kind, strings.Join(descriptions, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Kind
+}
+
+// NotFoundError is returned when the Port does not exist in a repository.
+type NotFoundError struct {
+	ID string
+}
+
+// NewNotFoundError creates NotFoundError of the Port with given ID.
+func NewNotFoundError(id string) *NotFoundError {
+	return &NotFoundError{ID: id}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%v: ID %v", ErrPortNotFound, e.ID)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return ErrPortNotFound
+}
+
+// ConflictError is returned when the expected version of the Port is not the version of the stored Port.
+type ConflictError struct {
+	ID              string
+	ExpectedVersion int64
+}
+
+// NewConflictError creates ConflictError of the Port with given ID and expected version.
+func NewConflictError(id string, expectedVersion int64) *ConflictError {
+	return &ConflictError{
+		ID:              id,
+		ExpectedVersion: expectedVersion,
+	}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v: ID %v, expected version %v", ErrVersionMismatch, e.ID, e.ExpectedVersion)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrVersionMismatch
+}
+
+// DuplicateUnlocError is returned when the Port to store lists in Unlocs a UN/LOCODE listed by another stored Port,
+// so that the code would not identify a single Port. IDs are not duplicates, as the Port with the ID is preferred.
+type DuplicateUnlocError struct {
+	ID string
+	// Unloc is the code listed by the Port and by the Port with OtherID.
+	Unloc   string
+	OtherID string
+}
+
+// NewDuplicateUnlocError creates DuplicateUnlocError of the Port with given ID.
+func NewDuplicateUnlocError(id, unloc, otherID string) *DuplicateUnlocError {
+	return &DuplicateUnlocError{
+		ID:      id,
+		Unloc:   unloc,
+		OtherID: otherID,
+	}
+}
+
+func (e *DuplicateUnlocError) Error() string {
+	return fmt.Sprintf(
+		"%v: %v of port with ID %v is listed by port with ID %v", ErrDuplicateUnloc, e.Unloc, e.ID, e.OtherID,
+	)
+}
+
+func (e *DuplicateUnlocError) Unwrap() error {
+	return ErrDuplicateUnloc
+}
+
+// RevisionCompactedError is returned when the events after the revision to resume watching from are not kept anymore.
+// The watcher should watch the events after the current revision and list the Ports again.
+type RevisionCompactedError struct {
+	Revision int64
+	// OldestRevision is the revision of the oldest kept event.
+	OldestRevision int64
+}
+
+// NewRevisionCompactedError creates RevisionCompactedError of given revision.
+func NewRevisionCompactedError(revision, oldestRevision int64) *RevisionCompactedError {
+	return &RevisionCompactedError{
+		Revision:       revision,
+		OldestRevision: oldestRevision,
+	}
+}
+
+func (e *RevisionCompactedError) Error() string {
+	return fmt.Sprintf("%v: events after revision %v are not kept, the oldest kept revision is %v",
+		ErrRevisionCompacted, e.Revision, e.OldestRevision)
+}
+
+func (e *RevisionCompactedError) Unwrap() error {
+	return ErrRevisionCompacted
+}
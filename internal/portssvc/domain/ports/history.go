@@ -0,0 +1,126 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HistoryEntry is an entry of the audit history of the Port. The Repository appends an entry for every change
+// of the Port in the same transaction as the change. History of purged Ports is kept.
+type HistoryEntry struct {
+	// Sequence identifies the entry in the Port history. Later entries have greater sequences.
+	Sequence int64
+	Type     EventType
+	// Previous is the Port stored before the change, including soft-deleted one. It is nil if no Port was stored.
+	Previous *Port
+	// Port is the Port after the change. It is nil if the Port was deleted or purged.
+	Port *Port
+	// Time is when the change was stored.
+	Time time.Time
+	// Caller identifies who made the change, see WithCaller. It is empty if the caller is unknown.
+	Caller string
+}
+
+// NewHistoryEntry returns the entry of the change stored now by the caller of given context.
+// The sequence is assigned by the Repository.
+func NewHistoryEntry(ctx context.Context, t EventType, previous, port *Port) HistoryEntry {
+	return HistoryEntry{
+		Type:     t,
+		Previous: previous,
+		Port:     port,
+		Time:     time.Now().UTC(),
+		Caller:   CallerFromContext(ctx),
+	}
+}
+
+// HistoryCursor points to a position in the Port history.
+type HistoryCursor struct {
+	// AfterSequence is a sequence of the last entry already listed. Zero points to the history beginning.
+	AfterSequence int64
+	// Limit is a maximum number of entries to list. Zero means no limit.
+	Limit int
+}
+
+// PortHistoryQuery is a query for a page of the Port history.
+type PortHistoryQuery struct {
+	// ID is the ID of the Port.
+	ID string
+	// PageSize is a maximum number of entries to list. DefaultPageSize is used if zero.
+	PageSize int
+	// PageToken is a NextPageToken returned by the previous query. Empty PageToken points to the first page.
+	PageToken string
+}
+
+// PortHistoryResult is a page of the Port history.
+type PortHistoryResult struct {
+	Entries []HistoryEntry
+	// NextPageToken allows to query the next page. It is empty if there are no more pages.
+	NextPageToken string
+}
+
+type callerContextKey struct{}
+
+// WithCaller returns a copy of the context with the identity of the caller, recorded in the history of the Ports
+// changed with that context.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerContextKey{}, caller)
+}
+
+// CallerFromContext returns the caller identity set with WithCaller, or empty string if it is not set.
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerContextKey{}).(string)
+	return caller
+}
+
+// GetPortHistory lists a page of the history of the Port with given ID, from the oldest entry. History of soft-deleted
+// and purged Ports is listed too. NotFoundError is returned if no change of the Port was recorded.
+func (s Service) GetPortHistory(ctx context.Context, q PortHistoryQuery) (PortHistoryResult, error) {
+	s.log.WithFields(logrus.Fields{
+		"port-id":   q.ID,
+		"page-size": q.PageSize,
+	}).Debug("Getting port history")
+
+	cursor, err := q.cursor()
+	if err != nil {
+		return PortHistoryResult{}, err
+	}
+
+	// Query one entry more than requested to find out whether the next page exists
+	pageSize := cursor.Limit
+	cursor.Limit++
+	entries, err := s.portsRepo.GetPortHistory(ctx, q.ID, cursor)
+	if err != nil {
+		return PortHistoryResult{}, err
+	}
+
+	result := PortHistoryResult{Entries: entries}
+	if len(entries) > pageSize {
+		result.Entries = entries[:pageSize]
+		result.NextPageToken = encodePageToken(strconv.FormatInt(result.Entries[pageSize-1].Sequence, 10))
+	}
+	return result, nil
+}
+
+func (q PortHistoryQuery) cursor() (HistoryCursor, error) {
+	page, err := ListPortsQuery{PageSize: q.PageSize, PageToken: q.PageToken}.cursor()
+	if err != nil {
+		return HistoryCursor{}, err
+	}
+
+	c := HistoryCursor{Limit: page.Limit}
+	if page.AfterID == "" {
+		return c, nil
+	}
+	c.AfterSequence, err = strconv.ParseInt(page.AfterID, 10, 64)
+	if err != nil || c.AfterSequence < 0 {
+		return HistoryCursor{}, newValidationError(ErrInvalidListQuery, FieldViolation{
+			Field:       "page_token",
+			Description: fmt.Sprintf("malformed page token: sequence %q", page.AfterID),
+		})
+	}
+	return c, nil
+}
@@ -0,0 +1,70 @@
+package ports
+
+import (
+	"fmt"
+	"math"
+)
+
+// EarthRadiusKm is the mean Earth radius used to calculate distances.
+const EarthRadiusKm = 6371.0088
+
+// LatLng is a geographic point in degrees.
+type LatLng struct {
+	Lat float64
+	Lng float64
+}
+
+// LatLngFromCoordinates converts legacy [longitude, latitude] coordinates to the point. Nil is returned for empty
+// coordinates, and ValidationError for coordinates that are not a pair.
+func LatLngFromCoordinates(coordinates []float64) (*LatLng, error) {
+	switch len(coordinates) {
+	case 0:
+		return nil, nil
+	case 2:
+		return &LatLng{Lat: coordinates[1], Lng: coordinates[0]}, nil
+	default:
+		return nil, newValidationError(ErrInvalidPort, FieldViolation{
+			Field:       "coordinates",
+			Description: fmt.Sprintf("coordinates must be [longitude, latitude], got %v values", len(coordinates)),
+		})
+	}
+}
+
+// Coordinates returns legacy [longitude, latitude] coordinates of the point, or nil for nil point.
+func (l *LatLng) Coordinates() []float64 {
+	if l == nil {
+		return nil
+	}
+	return []float64{l.Lng, l.Lat}
+}
+
+func (l LatLng) violations(field string) []FieldViolation {
+	var violations []FieldViolation
+	if math.IsNaN(l.Lat) || l.Lat < -90 || l.Lat > 90 {
+		violations = append(violations, FieldViolation{
+			Field:       field + ".latitude",
+			Description: fmt.Sprintf("latitude %v is out of range [-90, 90]", l.Lat),
+		})
+	}
+	if math.IsNaN(l.Lng) || l.Lng < -180 || l.Lng > 180 {
+		violations = append(violations, FieldViolation{
+			Field:       field + ".longitude",
+			Description: fmt.Sprintf("longitude %v is out of range [-180, 180]", l.Lng),
+		})
+	}
+	return violations
+}
+
+// DistanceKm returns the great-circle distance between the points calculated with the haversine formula.
+func DistanceKm(a, b LatLng) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	sinDLat := math.Sin((lat2 - lat1) / 2)
+	sinDLng := math.Sin(radians(b.Lng-a.Lng) / 2)
+
+	h := sinDLat*sinDLat + math.Cos(lat1)*math.Cos(lat2)*sinDLng*sinDLng
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
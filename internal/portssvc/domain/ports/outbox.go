@@ -0,0 +1,90 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OutboxBatchSize is a maximum number of pending PortChanged events read from the outbox at once.
+const OutboxBatchSize = 100
+
+// PortChanged is an event of the Port change. The Repository with enabled outbox records the event in the same
+// transaction as the change, so that the event is published eventually if and only if the change is stored.
+type PortChanged struct {
+	// Sequence identifies the event in the outbox. Events of the same Port have increasing sequences.
+	Sequence int64
+	Type     EventType
+	// Port is the Port after the change. Deleted Port is the last stored one.
+	Port Port
+	// Time is when the change was stored.
+	Time time.Time
+}
+
+// Publisher publishes PortChanged events, e.g. to a message bus.
+type Publisher interface {
+	// PublishPortChanged returns after the event is accepted by the destination.
+	PublishPortChanged(context.Context, PortChanged) error
+}
+
+// PublishPortChanges relays the events from the repository outbox to the publisher until the context is done.
+// Events are published in sequence order and removed from the outbox after they are published. Failed events
+// are retried after the poll interval, so events can be published more than once, but none is lost.
+// The outbox is polled with given interval and after every change made by the service.
+func (s Service) PublishPortChanges(ctx context.Context, p Publisher, pollInterval time.Duration) error {
+	s.log.WithField("poll-interval", pollInterval).Info("Publishing port changes")
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// The channel is taken before reading the outbox, so that changes made in the meantime are not missed
+		changed := s.changes.next()
+		n, err := s.publishPendingChanges(ctx, p)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			s.log.WithError(err).Warn("Failed to publish port changes")
+		}
+		if err == nil && n == OutboxBatchSize {
+			// More events are likely pending
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-changed:
+		}
+	}
+}
+
+// publishPendingChanges publishes a batch of pending events and returns the number of published ones.
+// Publishing stops on the first failure, so that the events are published in order.
+func (s Service) publishPendingChanges(ctx context.Context, p Publisher) (int, error) {
+	pending, err := s.portsRepo.PendingPortChanges(ctx, OutboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := make([]int64, 0, len(pending))
+	for _, e := range pending {
+		if err = p.PublishPortChanged(ctx, e); err != nil {
+			break
+		}
+		published = append(published, e.Sequence)
+	}
+
+	if len(published) > 0 {
+		if ackErr := s.portsRepo.AckPortChanges(ctx, published); ackErr != nil {
+			return 0, ackErr
+		}
+		s.log.WithFields(logrus.Fields{
+			"count":         len(published),
+			"last-sequence": published[len(published)-1],
+		}).Debug("Published port changes")
+	}
+	return len(published), err
+}
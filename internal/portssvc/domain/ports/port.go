@@ -1,30 +1,208 @@
 package ports
 
-import "errors"
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+)
 
-// Port is a domain entity.
+// Port is a domain entity. Location is nil if the port location is unknown.
 type Port struct {
-	ID          string
-	Name        string
-	City        string
-	Country     string
-	Alias       []string
-	Regions     []string
-	Coordinates []float64
-	Province    string
-	Timezone    string
-	Unlocs      []string
-	Code        string
-}
-
-// Validate checks if port's state is valid.
+	ID       string
+	Name     string
+	City     string
+	Country  string
+	Alias    []string
+	Regions  []string
+	Location *LatLng
+	Province string
+	Timezone string
+	Unlocs   []string
+	Code     string
+	// Version is incremented by the repository on every change of the Port. Non-zero Version of the Port to store
+	// is the expected version of the stored Port.
+	Version int64
+}
+
+// ContentHash returns a hash of the Port content, i.e. of all fields except Version. Ports with equal content have
+// equal hashes, with nil and empty lists considered equal.
+func (p Port) ContentHash() string {
+	h := sha256.New()
+	for _, s := range []string{p.ID, p.Name, p.City, p.Country, p.Province, p.Timezone, p.Code} {
+		hashString(h, s)
+	}
+	for _, list := range [][]string{p.Alias, p.Regions, p.Unlocs} {
+		hashInt(h, int64(len(list)))
+		for _, s := range list {
+			hashString(h, s)
+		}
+	}
+	if p.Location != nil {
+		hashInt(h, 1)
+		_ = binary.Write(h, binary.BigEndian, []float64{p.Location.Lat, p.Location.Lng})
+	} else {
+		hashInt(h, 0)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashString writes the string prefixed by its length to the hash, so that the boundaries of strings are hashed.
+func hashString(h hash.Hash, s string) {
+	hashInt(h, int64(len(s)))
+	_, _ = h.Write([]byte(s))
+}
+
+func hashInt(h hash.Hash, n int64) {
+	_ = binary.Write(h, binary.BigEndian, n)
+}
+
+// errNilPort is returned when nil Port is given to the Service.
+var errNilPort = newValidationError(ErrInvalidPort, FieldViolation{Field: "port", Description: "port is required"})
+
+// Validate checks if port's state is valid. ValidationError listing all invalid fields is returned otherwise.
+// The ID and Unlocs must be UN/LOCODEs. Location and Timezone are optional, but if set, Location must be within
+// valid ranges and Timezone must be a name from the IANA time zone database.
 func (p Port) Validate() error {
+	var violations []FieldViolation
+	addViolation := func(field, format string, args ...interface{}) {
+		violations = append(violations, FieldViolation{
+			Field:       field,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
 	if p.ID == "" {
-		return errors.New("ID is required")
+		addViolation("id", "ID is required")
+	} else if err := checkUNLOCODE(p.ID); err != nil {
+		addViolation("id", "ID %v", err)
 	}
+
 	if p.Name == "" {
-		return errors.New("name is required")
+		addViolation("name", "name is required")
+	}
+
+	if p.Location != nil {
+		violations = append(violations, p.Location.violations("location")...)
+	}
+
+	if p.Timezone != "" {
+		// Local is accepted by time.LoadLocation, but it is the time zone of the server rather than of the port
+		if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "Local" {
+			addViolation("timezone", "unknown IANA time zone %q", p.Timezone)
+		}
+	}
+
+	for i, unloc := range p.Unlocs {
+		if err := checkUNLOCODE(unloc); err != nil {
+			addViolation(fmt.Sprintf("unlocs[%d]", i), "UN/LOCODE %v", err)
+		}
+	}
+
+	if len(violations) > 0 {
+		return newValidationError(ErrInvalidPort, violations...)
+	}
+	return nil
+}
+
+// OtherUnlocs returns the distinct Unlocs of the Port other than its ID. The ID always identifies the Port, while
+// the other Unlocs identify it unless there is a Port with that ID. Each of them can be listed by one stored Port.
+func (p Port) OtherUnlocs() []string {
+	var unlocs []string
+	for _, unloc := range p.Unlocs {
+		if unloc != p.ID && !containsString(unlocs, unloc) {
+			unlocs = append(unlocs, unloc)
+		}
+	}
+	return unlocs
+}
+
+// NormalizeUNLOCODE returns the code in the canonical form of Port IDs, i.e. in upper case and without spaces,
+// so that codes written like "ae ajm" can be looked up.
+func NormalizeUNLOCODE(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, " ", ""))
+}
+
+// checkUNLOCODE checks that the code is a 5-character UN/LOCODE: ISO 3166-1 alpha-2 country code followed by
+// 3-character location code of letters and digits 2-9.
+func checkUNLOCODE(code string) error {
+	if len(code) != 5 {
+		return fmt.Errorf("%q must have 5 characters", code)
+	}
+	if _, ok := countryCodes[code[:2]]; !ok {
+		return fmt.Errorf("%q has unknown country code %q", code, code[:2])
+	}
+	for _, c := range code[2:] {
+		if (c < 'A' || c > 'Z') && (c < '2' || c > '9') {
+			return fmt.Errorf("%q has invalid location code %q", code, code[2:])
+		}
+	}
+	return nil
+}
+
+// UpdateFieldAll is an update path that selects all Port fields except ID.
+const UpdateFieldAll = "*"
+
+// portFieldSetters copy Port fields selected by update paths. Paths match the snake_case field names of the API.
+var portFieldSetters = map[string]func(dst *Port, src Port){
+	"name":     func(dst *Port, src Port) { dst.Name = src.Name },
+	"city":     func(dst *Port, src Port) { dst.City = src.City },
+	"country":  func(dst *Port, src Port) { dst.Country = src.Country },
+	"alias":    func(dst *Port, src Port) { dst.Alias = src.Alias },
+	"regions":  func(dst *Port, src Port) { dst.Regions = src.Regions },
+	"location": func(dst *Port, src Port) { dst.Location = src.Location },
+	// coordinates is the legacy name of location
+	"coordinates": func(dst *Port, src Port) { dst.Location = src.Location },
+	"province":    func(dst *Port, src Port) { dst.Province = src.Province },
+	"timezone":    func(dst *Port, src Port) { dst.Timezone = src.Timezone },
+	"unlocs":      func(dst *Port, src Port) { dst.Unlocs = src.Unlocs },
+	"code":        func(dst *Port, src Port) { dst.Code = src.Code },
+}
+
+// updatePathsField is the API name of update paths field.
+const updatePathsField = "update_mask.paths"
+
+// checkUpdatePaths checks that all update paths select updatable Port fields.
+func checkUpdatePaths(paths []string) error {
+	if len(paths) == 0 {
+		return newValidationError(ErrInvalidUpdate, FieldViolation{
+			Field:       updatePathsField,
+			Description: "no fields to update given",
+		})
 	}
 
+	var violations []FieldViolation
+	for _, path := range paths {
+		if path == UpdateFieldAll {
+			continue
+		}
+		if _, ok := portFieldSetters[path]; !ok {
+			violations = append(violations, FieldViolation{
+				Field:       updatePathsField,
+				Description: fmt.Sprintf("field %q cannot be updated", path),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return newValidationError(ErrInvalidUpdate, violations...)
+	}
 	return nil
 }
+
+// applyUpdate sets fields of the Port selected by update paths to the values of given update.
+// The paths must be checked with checkUpdatePaths.
+func (p *Port) applyUpdate(update Port, paths []string) {
+	for _, path := range paths {
+		if path == UpdateFieldAll {
+			for _, set := range portFieldSetters {
+				set(p, update)
+			}
+			continue
+		}
+		portFieldSetters[path](p, update)
+	}
+}
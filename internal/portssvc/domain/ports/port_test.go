@@ -0,0 +1,214 @@
+package ports_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPort_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		port func(p *ports.Port)
+		// expectedFields are fields of expected violations, nil if the port is valid
+		expectedFields []string
+	}{
+		{
+			name: "valid port given",
+			port: func(p *ports.Port) {},
+		}, {
+			name: "port without optional fields given",
+			port: func(p *ports.Port) {
+				p.Location = nil
+				p.Timezone = ""
+				p.Unlocs = nil
+			},
+		}, {
+			name: "empty port given",
+			port: func(p *ports.Port) {
+				*p = ports.Port{}
+			},
+			expectedFields: []string{"id", "name"},
+		}, {
+			name: "ID with invalid length given",
+			port: func(p *ports.Port) {
+				p.ID = "AEAJMX"
+			},
+			expectedFields: []string{"id"},
+		}, {
+			name: "ID with unknown country given",
+			port: func(p *ports.Port) {
+				p.ID = "QQAJM"
+			},
+			expectedFields: []string{"id"},
+		}, {
+			name: "ID with invalid location given",
+			port: func(p *ports.Port) {
+				p.ID = "AEA1M"
+			},
+			expectedFields: []string{"id"},
+		}, {
+			name: "location out of range given",
+			port: func(p *ports.Port) {
+				p.Location = &ports.LatLng{Lat: math.NaN(), Lng: -180.5}
+			},
+			expectedFields: []string{"location.latitude", "location.longitude"},
+		}, {
+			name: "unknown timezone given",
+			port: func(p *ports.Port) {
+				p.Timezone = "Asia/Ajman"
+			},
+			expectedFields: []string{"timezone"},
+		}, {
+			name: "timezone region given",
+			port: func(p *ports.Port) {
+				p.Timezone = "America/Argentina"
+			},
+			expectedFields: []string{"timezone"},
+		}, {
+			name: "local timezone given",
+			port: func(p *ports.Port) {
+				p.Timezone = "Local"
+			},
+			expectedFields: []string{"timezone"},
+		}, {
+			name: "multiple violations given",
+			port: func(p *ports.Port) {
+				p.ID = "ae-ajm"
+				p.Location = &ports.LatLng{Lat: 95.5, Lng: 25.4}
+				p.Timezone = "Dubai"
+				p.Unlocs = []string{"AEAJM", "AE", "aeajm"}
+			},
+			expectedFields: []string{"id", "location.latitude", "timezone", "unlocs[1]", "unlocs[2]"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			p := newAjmanPort()
+			tt.port(p)
+
+			// When
+			err := p.Validate()
+
+			// Then
+			if tt.expectedFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *ports.ValidationError
+			require.True(t, errors.As(err, &validationErr), "unexpected error: %v", err)
+			assert.ErrorIs(t, err, ports.ErrInvalidPort)
+
+			fields := make([]string, 0, len(validationErr.Violations))
+			for _, v := range validationErr.Violations {
+				fields = append(fields, v.Field)
+			}
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
+
+func TestLatLngFromCoordinates(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		coordinates      []float64
+		expectedError    bool
+		expectedLocation *ports.LatLng
+	}{
+		{
+			name: "no coordinates given",
+		}, {
+			name:             "longitude and latitude given",
+			coordinates:      []float64{55.5136433, 25.4052165},
+			expectedLocation: &ports.LatLng{Lat: 25.4052165, Lng: 55.5136433},
+		}, {
+			name:          "single coordinate given",
+			coordinates:   []float64{55.5},
+			expectedError: true,
+		}, {
+			name:          "three coordinates given",
+			coordinates:   []float64{55.5, 25.4, 0},
+			expectedError: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			location, err := ports.LatLngFromCoordinates(tt.coordinates)
+
+			// Then
+			if tt.expectedError {
+				assert.ErrorIs(t, err, ports.ErrInvalidPort)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedLocation, location)
+			if location != nil {
+				assert.Equal(t, tt.coordinates, location.Coordinates())
+			}
+		})
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		a, b       ports.LatLng
+		expectedKm float64
+	}{
+		{
+			name:       "same point given",
+			a:          ports.LatLng{Lat: 25.4052165, Lng: 55.5136433},
+			b:          ports.LatLng{Lat: 25.4052165, Lng: 55.5136433},
+			expectedKm: 0,
+		}, {
+			name:       "London and Paris given",
+			a:          ports.LatLng{Lat: 51.5074, Lng: -0.1278},
+			b:          ports.LatLng{Lat: 48.8566, Lng: 2.3522},
+			expectedKm: 343.6,
+		}, {
+			name:       "points across the antimeridian given",
+			a:          ports.LatLng{Lat: 0, Lng: 179.5},
+			b:          ports.LatLng{Lat: 0, Lng: -179.5},
+			expectedKm: 111.2,
+		}, {
+			name:       "antipodal points given",
+			a:          ports.LatLng{Lat: 90, Lng: 0},
+			b:          ports.LatLng{Lat: -90, Lng: 0},
+			expectedKm: math.Pi * ports.EarthRadiusKm,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expectedKm, ports.DistanceKm(tt.a, tt.b), 0.1)
+		})
+	}
+}
+
+func TestPort_OtherUnlocs(t *testing.T) {
+	p := ports.Port{ID: "CNDAL", Unlocs: []string{"CNDLC", "CNDAL", "CNDLC"}}
+
+	assert.Equal(t, []string{"CNDLC"}, p.OtherUnlocs())
+}
+
+func TestPort_ContentHash(t *testing.T) {
+	p := ports.Port{ID: "AEAJM", Name: "Ajman", Alias: []string{}, Location: &ports.LatLng{Lat: 25.4, Lng: 55.5}}
+
+	sameContent := p
+	sameContent.Alias = nil
+	sameContent.Version = 2
+	assert.Equal(t, p.ContentHash(), sameContent.ContentHash(), "nil list and version should not change the hash")
+
+	for _, changed := range []ports.Port{
+		{ID: "AEAJM", Name: "Ajman Port", Location: p.Location},
+		{ID: "AEAJM", Name: "Ajman", Alias: []string{""}, Location: p.Location},
+		{ID: "AEAJM", Name: "Ajman"},
+		{ID: "AEAJM", Name: "Ajman", Location: &ports.LatLng{Lat: 25.4, Lng: 55.6}},
+		{ID: "AEAJM", City: "Ajman", Location: p.Location},
+	} {
+		assert.NotEqual(t, p.ContentHash(), changed.ContentHash(), "port: %+v", changed)
+	}
+}
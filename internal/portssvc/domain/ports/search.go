@@ -0,0 +1,71 @@
+package ports
+
+import "strings"
+
+// PortFilter selects Ports matching all of its non-empty fields. Empty PortFilter matches all Ports.
+type PortFilter struct {
+	Country  string
+	Province string
+	// Region matches Ports with the region among their Regions.
+	Region   string
+	Timezone string
+	Code     string
+	// NamePrefix matches Ports with the name or any alias starting with it, ignoring case.
+	NamePrefix string
+}
+
+// SearchPortsQuery is a query for a page of Ports matching the filter.
+type SearchPortsQuery struct {
+	Filter PortFilter
+	// PageSize is a maximum number of Ports to list. DefaultPageSize is used if zero.
+	PageSize int
+	// PageToken is a NextPageToken returned by the previous query with the same filter. Empty PageToken points
+	// to the first page.
+	PageToken string
+}
+
+// Matches reports whether the Port matches the filter.
+func (f PortFilter) Matches(p Port) bool {
+	return matchesValue(f.Country, p.Country) &&
+		matchesValue(f.Province, p.Province) &&
+		matchesValue(f.Timezone, p.Timezone) &&
+		matchesValue(f.Code, p.Code) &&
+		(f.Region == "" || containsString(p.Regions, f.Region)) &&
+		(f.NamePrefix == "" || p.hasNamePrefix(FoldName(f.NamePrefix)))
+}
+
+// IsEmpty reports whether the filter matches all Ports.
+func (f PortFilter) IsEmpty() bool {
+	return f == PortFilter{}
+}
+
+// FoldName returns the case-folded form of the Port name or alias used for prefix matching.
+func FoldName(name string) string {
+	return strings.ToLower(name)
+}
+
+// hasNamePrefix reports whether the name or any alias of the Port starts with the case-folded prefix.
+func (p Port) hasNamePrefix(foldedPrefix string) bool {
+	if strings.HasPrefix(FoldName(p.Name), foldedPrefix) {
+		return true
+	}
+	for _, alias := range p.Alias {
+		if strings.HasPrefix(FoldName(alias), foldedPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesValue(filter, value string) bool {
+	return filter == "" || filter == value
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
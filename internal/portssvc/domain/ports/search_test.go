@@ -0,0 +1,71 @@
+package ports_test
+
+import (
+	"testing"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortFilter_Matches(t *testing.T) {
+	port := ports.Port{
+		ID:       "AEAJM",
+		Name:     "Ajman",
+		Country:  "United Arab Emirates",
+		Province: "Ajman",
+		Alias:    []string{"Ujman"},
+		Regions:  []string{"Middle East", "Persian Gulf"},
+		Timezone: "Asia/Dubai",
+		Code:     "52000",
+	}
+
+	for _, tt := range []struct {
+		name     string
+		filter   ports.PortFilter
+		expected bool
+	}{
+		{
+			name:     "empty filter given",
+			expected: true,
+		}, {
+			name: "all fields matching given",
+			filter: ports.PortFilter{
+				Country:    "United Arab Emirates",
+				Province:   "Ajman",
+				Region:     "Persian Gulf",
+				Timezone:   "Asia/Dubai",
+				Code:       "52000",
+				NamePrefix: "aj",
+			},
+			expected: true,
+		}, {
+			name:     "different country given",
+			filter:   ports.PortFilter{Country: "Oman"},
+			expected: false,
+		}, {
+			name:     "country differing in case given",
+			filter:   ports.PortFilter{Country: "united arab emirates"},
+			expected: false,
+		}, {
+			name:     "different code and matching country given",
+			filter:   ports.PortFilter{Country: "United Arab Emirates", Code: "52001"},
+			expected: false,
+		}, {
+			name:     "region not among port regions given",
+			filter:   ports.PortFilter{Region: "Middle"},
+			expected: false,
+		}, {
+			name:     "alias prefix in different case given",
+			filter:   ports.PortFilter{NamePrefix: "UJM"},
+			expected: true,
+		}, {
+			name:     "name infix given",
+			filter:   ports.PortFilter{NamePrefix: "jman"},
+			expected: false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.Matches(port))
+		})
+	}
+}
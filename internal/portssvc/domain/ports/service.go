@@ -3,21 +3,151 @@ package ports
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"math"
 
 	"github.com/danielfurman/ports-microservices/internal/logs"
 	"github.com/sirupsen/logrus"
 )
 
-// Repository defines interface for storing Ports.
+const (
+	// DefaultPageSize is a number of Ports listed when page size is not specified.
+	DefaultPageSize = 100
+	// MaxPageSize is a maximum number of Ports listed in one page. Larger page sizes are coerced to it.
+	MaxPageSize = 1000
+	// DefaultNearbyLimit is a number of nearby Ports found when the limit is not specified.
+	DefaultNearbyLimit = 10
+	// MaxNearbyLimit is a maximum number of nearby Ports found at once. Larger limits are coerced to it.
+	MaxNearbyLimit = 1000
+
+	streamBatchSize = 100
+)
+
+// StoreOutcome tells how storing the Port changed the stored one.
+type StoreOutcome int
+
+// Store outcomes.
+const (
+	// StoreCreated means that the Port was stored with an ID of no Port, or of a soft-deleted one.
+	StoreCreated StoreOutcome = iota + 1
+	// StoreUpdated means that the stored Port was replaced with the Port of different content.
+	StoreUpdated
+	// StoreUnchanged means that the stored Port has the same content, so it was not changed.
+	StoreUnchanged
+)
+
+func (o StoreOutcome) String() string {
+	switch o {
+	case StoreCreated:
+		return "created"
+	case StoreUpdated:
+		return "updated"
+	case StoreUnchanged:
+		return "unchanged"
+	default:
+		return fmt.Sprintf("StoreOutcome(%d)", int(o))
+	}
+}
+
+// Repository defines interface for storing Ports. NotFoundError is returned for Ports that do not exist.
+// Soft-deleted Ports are hidden from GetPort and ListPorts. Storing a Port with the ID of soft-deleted Port
+// replaces it with the new, not deleted Port.
+// Unlocs of Ports that are not soft-deleted, other than their IDs, are unique: DuplicateUnlocError is returned when
+// storing, updating or restoring a Port with such an Unloc of another Port. An ID of a Port can be an Unloc of
+// another Port, but the code identifies the Port with that ID.
+// Every change of the Port appends a HistoryEntry to its history, attributed to the caller of the change context.
+// Repository with enabled outbox records a PortChanged event of every stored, updated, deleted, restored and purged
+// Port in the same transaction as the change.
 type Repository interface {
-	StorePort(context.Context, *Port) error
-	ListPorts(context.Context) ([]Port, error)
+	// StorePort stores the Port with the next version and returns the stored Port with the outcome. The stored Port
+	// with the same content, as compared by ContentHash, is not changed and keeps its version. If the Port has
+	// non-zero Version, ConflictError is returned unless it is the version of the stored Port.
+	StorePort(context.Context, *Port) (stored Port, outcome StoreOutcome, err error)
+	// GetPort returns the Port with given ID.
+	GetPort(ctx context.Context, id string) (Port, error)
+	// LookupPort returns the Port identified by given UN/LOCODE, i.e. with that ID, or else with that Unlocs entry.
+	LookupPort(ctx context.Context, unloc string) (Port, error)
+	// ListPorts lists Ports ordered by ID, starting after the cursor position.
+	ListPorts(context.Context, Cursor) ([]Port, error)
+	// SearchPorts lists Ports matching the filter ordered by ID, starting after the cursor position.
+	SearchPorts(context.Context, PortFilter, Cursor) ([]Port, error)
+	// SuggestPorts finds Ports matching the text query, ordered by descending TextMatcher score and ascending ID.
+	// The query is already validated, so its text has terms and its limit is positive.
+	SuggestPorts(context.Context, TextQuery) ([]ScoredPort, error)
+	// FindNearbyPorts finds Ports with location nearest to the query location, ordered by distance and ID.
+	// The query is already validated, so its location is set and its limit is positive.
+	FindNearbyPorts(context.Context, NearbyQuery) ([]NearbyPort, error)
+	// UpdatePort atomically applies the update function to a copy of the Port with given ID and stores the result
+	// with the next version.
+	// The Port is not stored if the function returns an error.
+	UpdatePort(ctx context.Context, id string, update func(*Port) error) (Port, error)
+	// DeletePort soft-deletes the Port with given ID and returns it with the next version.
+	DeletePort(ctx context.Context, id string) (Port, error)
+	// RestorePort restores the soft-deleted Port with given ID and returns it with the next version.
+	RestorePort(ctx context.Context, id string) (Port, error)
+	// PurgePort permanently removes the Port with given ID, including soft-deleted one, and returns it.
+	PurgePort(ctx context.Context, id string) (Port, error)
+	// GetPortHistory lists HistoryEntry records of the Port with given ID ordered by sequence, starting after
+	// the cursor position. NotFoundError is returned if no change of the Port was recorded.
+	GetPortHistory(ctx context.Context, id string, c HistoryCursor) ([]HistoryEntry, error)
+
+	// PendingPortChanges returns up to limit oldest PortChanged events of the outbox, ordered by sequence.
+	// No events are returned if the outbox is not enabled.
+	PendingPortChanges(ctx context.Context, limit int) ([]PortChanged, error)
+	// AckPortChanges removes the published events with given sequences from the outbox.
+	AckPortChanges(ctx context.Context, sequences []int64) error
+}
+
+// Cursor points to a position in the list of Ports ordered by ID.
+type Cursor struct {
+	// AfterID is an ID of the last Port already listed. Empty AfterID points to the list beginning.
+	AfterID string
+	// Limit is a maximum number of Ports to list. Zero means no limit.
+	Limit int
+}
+
+// NearbyQuery is a query for Ports nearest to the location.
+type NearbyQuery struct {
+	// Location is required.
+	Location *LatLng
+	// RadiusKm is a maximum distance of the Ports from the location. Zero means no limit.
+	RadiusKm float64
+	// Limit is a maximum number of Ports to find. DefaultNearbyLimit is used if zero.
+	Limit int
+}
+
+// NearbyPort is a Port found by NearbyQuery.
+type NearbyPort struct {
+	Port Port
+	// DistanceKm is a great-circle distance of the Port from the query location.
+	DistanceKm float64
+}
+
+// ListPortsQuery is a query for a page of Ports.
+type ListPortsQuery struct {
+	// PageSize is a maximum number of Ports to list. DefaultPageSize is used if zero.
+	PageSize int
+	// PageToken is a NextPageToken returned by the previous query. Empty PageToken points to the first page.
+	PageToken string
+}
+
+// ListPortsResult is a page of Ports.
+type ListPortsResult struct {
+	Ports []Port
+	// NextPageToken allows to query the next page. It is empty if there are no more pages.
+	NextPageToken string
 }
 
-// Service is a service that allows to store and list Ports.
+// Service is a service that allows to store, get, list, search, update and delete Ports, to watch their changes
+// and to read their history.
 type Service struct {
 	portsRepo Repository
+	// changes is a feed of the Port changes made by the service. Changes made by other service instances sharing
+	// the repository are not included.
+	changes *changeFeed
+	// portLocks are held from changing the Port in the repository until its event is published.
+	portLocks *portLocks
 	log       *logrus.Entry
 }
 
@@ -25,28 +155,324 @@ type Service struct {
 func NewService(pr Repository) Service {
 	return Service{
 		portsRepo: pr,
+		changes:   newChangeFeed(ChangeHistorySize),
+		portLocks: newPortLocks(),
 		log:       logs.NewLogger("ports-service"),
 	}
 }
 
-// StorePort stores given Port in a repository.
-func (s Service) StorePort(ctx context.Context, port *Port) error {
+// StorePort stores given Port in a repository and returns the outcome. The stored Port with the same content is not
+// changed, so storing the same Port again records no history entry and no event. ValidationError is returned if
+// the Port is not valid, ConflictError if the Port has non-zero Version that is not the version of the stored Port,
+// and DuplicateUnlocError if any of the Port Unlocs other than its ID is an Unloc of another Port.
+func (s Service) StorePort(ctx context.Context, port *Port) (StoreOutcome, error) {
+	if port == nil {
+		return 0, errNilPort
+	}
+
+	if err := port.Validate(); err != nil {
+		return 0, err
+	}
+
+	defer s.portLocks.lock(port.ID)()
+	stored, outcome, err := s.portsRepo.StorePort(ctx, port)
+	if err != nil {
+		return 0, err
+	}
+
+	// Unchanged Ports are not logged, so that storing the same file again does not flood the log
+	switch outcome {
+	case StoreCreated:
+		s.log.WithField("port-id", port.ID).Debug("Created port")
+		s.changes.publish(EventCreated, stored)
+	case StoreUpdated:
+		s.log.WithField("port-id", port.ID).Debug("Updated port")
+		s.changes.publish(EventUpdated, stored)
+	}
+	return outcome, nil
+}
+
+// GetPort returns the Port with given ID. NotFoundError is returned if there is no such Port.
+func (s Service) GetPort(ctx context.Context, id string) (Port, error) {
+	s.log.WithField("port-id", id).Debug("Getting port")
+	return s.portsRepo.GetPort(ctx, id)
+}
+
+// LookupPort returns the Port identified by given UN/LOCODE, which is either the Port ID or any of its Unlocs.
+// The Port with that ID is preferred over the Port listing the code in Unlocs. The code is normalized with
+// NormalizeUNLOCODE. NotFoundError is returned if there is no such Port.
+func (s Service) LookupPort(ctx context.Context, unloc string) (Port, error) {
+	s.log.WithField("unloc", unloc).Debug("Looking up port")
+	return s.portsRepo.LookupPort(ctx, NormalizeUNLOCODE(unloc))
+}
+
+// UpdatePort updates fields of the stored Port selected by update paths to the values of given Port and returns
+// the updated Port. Paths are snake_case field names, UpdateFieldAll selects all fields except ID.
+// ValidationError is returned if the paths or the updated Port are not valid, NotFoundError if there is no Port with
+// the ID of given one, ConflictError if given Port has non-zero Version that is not the version of the stored Port,
+// and DuplicateUnlocError if any of the updated Port Unlocs other than its ID is an Unloc of another Port.
+func (s Service) UpdatePort(ctx context.Context, port *Port, paths []string) (Port, error) {
 	if port == nil {
-		return fmt.Errorf("nil port given")
+		return Port{}, errNilPort
+	}
+	s.log.WithFields(logrus.Fields{
+		"port-id": port.ID,
+		"paths":   paths,
+	}).Debug("Updating port")
+
+	if err := checkUpdatePaths(paths); err != nil {
+		return Port{}, err
+	}
+
+	defer s.portLocks.lock(port.ID)()
+	updated, err := s.portsRepo.UpdatePort(ctx, port.ID, func(stored *Port) error {
+		if port.Version != 0 && port.Version != stored.Version {
+			return NewConflictError(port.ID, port.Version)
+		}
+		stored.applyUpdate(*port, paths)
+		return stored.Validate()
+	})
+	if err != nil {
+		return Port{}, err
 	}
-	s.log.WithField("port-id", port.ID).Debug("Storing port")
 
-	err := port.Validate()
+	s.changes.publish(EventUpdated, updated)
+	return updated, nil
+}
+
+// DeletePort deletes the Port with given ID. Soft-deleted Port is hidden, but it can be restored with RestorePort.
+// Purged Port is removed permanently. NotFoundError is returned if there is no such Port.
+// Purging a soft-deleted Port emits the deleted event again.
+func (s Service) DeletePort(ctx context.Context, id string, purge bool) error {
+	s.log.WithFields(logrus.Fields{
+		"port-id": id,
+		"purge":   purge,
+	}).Debug("Deleting port")
+
+	var (
+		deleted Port
+		err     error
+	)
+	defer s.portLocks.lock(id)()
+	if purge {
+		deleted, err = s.portsRepo.PurgePort(ctx, id)
+	} else {
+		deleted, err = s.portsRepo.DeletePort(ctx, id)
+	}
 	if err != nil {
-		return fmt.Errorf("validate port: %w", err)
+		return err
 	}
 
-	return s.portsRepo.StorePort(ctx, port)
+	s.changes.publish(EventDeleted, deleted)
+	return nil
 }
 
-// ListPorts lists all Ports stored in the repository of the service.
-func (s Service) ListPorts(ctx context.Context) ([]Port, error) {
-	s.log.Debug("Listing ports")
-	ports, err := s.portsRepo.ListPorts(ctx)
-	return ports, err
+// RestorePort restores the soft-deleted Port with given ID. NotFoundError is returned if there is no such Port,
+// and DuplicateUnlocError if any of its Unlocs other than its ID was listed by another Port after the Port was deleted.
+func (s Service) RestorePort(ctx context.Context, id string) (Port, error) {
+	s.log.WithField("port-id", id).Debug("Restoring port")
+	defer s.portLocks.lock(id)()
+	restored, err := s.portsRepo.RestorePort(ctx, id)
+	if err != nil {
+		return Port{}, err
+	}
+
+	s.changes.publish(EventCreated, restored)
+	return restored, nil
+}
+
+// PortsRevision returns the revision of the latest Port event, so that the events after it can be watched.
+func (s Service) PortsRevision() int64 {
+	return s.changes.revision()
+}
+
+// WatchPorts calls send for the events of the Port changes made after the query revision, waiting for the new ones
+// until the context is done or send fails. ValidationError is returned if the query is not valid,
+// and RevisionCompactedError if the events after the query revision are not kept anymore.
+// Events of the same Port are sent in the order its changes were stored.
+// Watching stops with an error if the watcher does not keep up with ChangeHistorySize events.
+func (s Service) WatchPorts(ctx context.Context, q WatchQuery, send func(PortEvent) error) error {
+	s.log.WithField("after-revision", q.AfterRevision).Debug("Watching ports")
+	return s.changes.watch(ctx, q, send)
+}
+
+// FindNearbyPorts finds Ports nearest to the query location, ordered by distance. Ports without location are
+// never found. ValidationError is returned if the query is not valid.
+func (s Service) FindNearbyPorts(ctx context.Context, q NearbyQuery) ([]NearbyPort, error) {
+	s.log.WithFields(logrus.Fields{
+		"location":  q.Location,
+		"radius-km": q.RadiusKm,
+		"limit":     q.Limit,
+	}).Debug("Finding nearby ports")
+
+	q, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+	return s.portsRepo.FindNearbyPorts(ctx, q)
+}
+
+// SuggestPorts finds Ports with the name, aliases, city or province matching the query text, ordered from the best
+// matching ones. It tolerates typos and incomplete last word, so it is suitable for autocomplete.
+// ValidationError is returned if the query is not valid.
+func (s Service) SuggestPorts(ctx context.Context, q TextQuery) ([]ScoredPort, error) {
+	s.log.WithFields(logrus.Fields{
+		"text":  q.Text,
+		"limit": q.Limit,
+	}).Debug("Suggesting ports")
+
+	q, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+	return s.portsRepo.SuggestPorts(ctx, q)
+}
+
+// ListPorts lists a page of Ports stored in the repository of the service. Ports are ordered by ID.
+func (s Service) ListPorts(ctx context.Context, q ListPortsQuery) (ListPortsResult, error) {
+	s.log.WithField("page-size", q.PageSize).Debug("Listing ports")
+	return listPage(q, func(c Cursor) ([]Port, error) {
+		return s.portsRepo.ListPorts(ctx, c)
+	})
+}
+
+// SearchPorts lists a page of Ports matching the query filter. Ports are ordered by ID.
+func (s Service) SearchPorts(ctx context.Context, q SearchPortsQuery) (ListPortsResult, error) {
+	s.log.WithFields(logrus.Fields{
+		"filter":    q.Filter,
+		"page-size": q.PageSize,
+	}).Debug("Searching ports")
+	page := ListPortsQuery{
+		PageSize:  q.PageSize,
+		PageToken: q.PageToken,
+	}
+	return listPage(page, func(c Cursor) ([]Port, error) {
+		return s.portsRepo.SearchPorts(ctx, q.Filter, c)
+	})
+}
+
+// listPage lists the page of Ports with given list function.
+func listPage(q ListPortsQuery, list func(Cursor) ([]Port, error)) (ListPortsResult, error) {
+	cursor, err := q.cursor()
+	if err != nil {
+		return ListPortsResult{}, err
+	}
+
+	// Query one Port more than requested to find out whether the next page exists
+	pageSize := cursor.Limit
+	cursor.Limit++
+	ports, err := list(cursor)
+	if err != nil {
+		return ListPortsResult{}, err
+	}
+
+	result := ListPortsResult{Ports: ports}
+	if len(ports) > pageSize {
+		result.Ports = ports[:pageSize]
+		result.NextPageToken = encodePageToken(result.Ports[pageSize-1].ID)
+	}
+	return result, nil
+}
+
+// StreamPorts calls send for each Port stored in the repository of the service, in ID order.
+// Ports are read from the repository in small batches, so the repository is not locked while a slow consumer
+// processes them. Streaming stops on context cancel/timeout or on the first send error.
+func (s Service) StreamPorts(ctx context.Context, send func(Port) error) error {
+	s.log.Debug("Streaming ports")
+	cursor := Cursor{Limit: streamBatchSize}
+	for {
+		batch, err := s.portsRepo.ListPorts(ctx, cursor)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range batch {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			if err = send(p); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < cursor.Limit {
+			return nil
+		}
+		cursor.AfterID = batch[len(batch)-1].ID
+	}
+}
+
+func (q ListPortsQuery) cursor() (Cursor, error) {
+	if q.PageSize < 0 {
+		return Cursor{}, newValidationError(ErrInvalidListQuery, FieldViolation{
+			Field:       "page_size",
+			Description: fmt.Sprintf("negative page size %v", q.PageSize),
+		})
+	}
+
+	afterID, err := decodePageToken(q.PageToken)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	limit := q.PageSize
+	switch {
+	case limit == 0:
+		limit = DefaultPageSize
+	case limit > MaxPageSize:
+		limit = MaxPageSize
+	}
+
+	return Cursor{
+		AfterID: afterID,
+		Limit:   limit,
+	}, nil
+}
+
+// normalize validates the query and applies the default limit.
+func (q NearbyQuery) normalize() (NearbyQuery, error) {
+	var violations []FieldViolation
+	if q.Location == nil {
+		violations = append(violations, FieldViolation{Field: "location", Description: "location is required"})
+	} else {
+		violations = append(violations, q.Location.violations("location")...)
+	}
+	if math.IsNaN(q.RadiusKm) || q.RadiusKm < 0 {
+		violations = append(violations, FieldViolation{
+			Field:       "radius_km",
+			Description: fmt.Sprintf("radius %v must not be negative", q.RadiusKm),
+		})
+	}
+	if q.Limit < 0 {
+		violations = append(violations, FieldViolation{
+			Field:       "limit",
+			Description: fmt.Sprintf("limit %v must not be negative", q.Limit),
+		})
+	}
+	if len(violations) > 0 {
+		return NearbyQuery{}, newValidationError(ErrInvalidNearbyQuery, violations...)
+	}
+
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultNearbyLimit
+	case q.Limit > MaxNearbyLimit:
+		q.Limit = MaxNearbyLimit
+	}
+	return q, nil
+}
+
+func encodePageToken(lastID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastID))
+}
+
+func decodePageToken(token string) (string, error) {
+	lastID, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", newValidationError(ErrInvalidListQuery, FieldViolation{
+			Field:       "page_token",
+			Description: fmt.Sprintf("malformed page token: %v", err),
+		})
+	}
+	return string(lastID), nil
 }
@@ -2,11 +2,17 @@ package ports_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/danielfurman/ports-microservices/internal/portssvc/adapter"
 	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_StorePort(t *testing.T) {
@@ -28,7 +34,7 @@ func TestService_StorePort(t *testing.T) {
 			name:      "valid port given",
 			inputPort: newAjmanPort(),
 			expectedPorts: []ports.Port{
-				*newAjmanPort(),
+				storedPort(newAjmanPort(), 1),
 			},
 		}, {
 			name: "port with empty ID given",
@@ -56,7 +62,7 @@ func TestService_StorePort(t *testing.T) {
 			service := ports.NewService(adapter.NewInMemoryPortsRepository())
 
 			// When
-			err := service.StorePort(context.Background(), tt.inputPort)
+			_, err := service.StorePort(context.Background(), tt.inputPort)
 
 			// Then
 			if tt.expectedError {
@@ -65,25 +71,498 @@ func TestService_StorePort(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			ps, err := service.ListPorts(ctx)
+			result, err := service.ListPorts(ctx, ports.ListPortsQuery{})
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedPorts, ps)
+			assert.Equal(t, tt.expectedPorts, result.Ports)
+			assert.Empty(t, result.NextPageToken)
 		})
 	}
 }
 
+func TestService_GetPort(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	service := ports.NewService(adapter.NewInMemoryPortsRepository())
+	storePort(ctx, t, service, newAjmanPort())
+
+	// When
+	p, err := service.GetPort(ctx, "AEAJM")
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, storedPort(newAjmanPort(), 1), p)
+
+	// When
+	_, err = service.GetPort(ctx, "AEDXB")
+
+	// Then
+	assert.ErrorIs(t, err, ports.ErrPortNotFound)
+}
+
+func TestService_UpdatePort(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		inputPort     *ports.Port
+		inputPaths    []string
+		expectedError error
+		expectedPort  ports.Port
+	}{
+		{
+			name:          "nil port given",
+			inputPaths:    []string{"timezone"},
+			expectedError: ports.ErrInvalidPort,
+			expectedPort:  storedPort(newAjmanPort(), 1),
+		}, {
+			name:          "no paths given",
+			inputPort:     &ports.Port{ID: "AEAJM", Timezone: "Asia/Muscat"},
+			expectedError: ports.ErrInvalidUpdate,
+			expectedPort:  storedPort(newAjmanPort(), 1),
+		}, {
+			name:          "ID path given",
+			inputPort:     &ports.Port{ID: "AEAJM"},
+			inputPaths:    []string{"id"},
+			expectedError: ports.ErrInvalidUpdate,
+			expectedPort:  storedPort(newAjmanPort(), 1),
+		}, {
+			name:          "unknown path given",
+			inputPort:     &ports.Port{ID: "AEAJM"},
+			inputPaths:    []string{"timezone", "unknown"},
+			expectedError: ports.ErrInvalidUpdate,
+			expectedPort:  storedPort(newAjmanPort(), 1),
+		}, {
+			name:          "unknown port given",
+			inputPort:     &ports.Port{ID: "AEDXB", Timezone: "Asia/Muscat"},
+			inputPaths:    []string{"timezone"},
+			expectedError: ports.ErrPortNotFound,
+			expectedPort:  storedPort(newAjmanPort(), 1),
+		}, {
+			name:          "update makes port invalid",
+			inputPort:     &ports.Port{ID: "AEAJM"},
+			inputPaths:    []string{"name"},
+			expectedError: ports.ErrInvalidPort,
+			expectedPort:  storedPort(newAjmanPort(), 1),
+		}, {
+			name:       "fields update given",
+			inputPort:  &ports.Port{ID: "AEAJM", Name: "Ignored", Timezone: "Asia/Muscat", Alias: []string{"baz"}},
+			inputPaths: []string{"timezone", "alias"},
+			expectedPort: func() ports.Port {
+				p := storedPort(newAjmanPort(), 2)
+				p.Timezone = "Asia/Muscat"
+				p.Alias = []string{"baz"}
+				return p
+			}(),
+		}, {
+			name:         "full update given",
+			inputPort:    &ports.Port{ID: "AEAJM", Name: "Ajman Port"},
+			inputPaths:   []string{ports.UpdateFieldAll},
+			expectedPort: ports.Port{ID: "AEAJM", Name: "Ajman Port", Version: 2},
+		}, {
+			name:          "stale version given",
+			inputPort:     &ports.Port{ID: "AEAJM", Timezone: "Asia/Muscat", Version: 2},
+			inputPaths:    []string{"timezone"},
+			expectedError: ports.ErrVersionMismatch,
+			expectedPort:  storedPort(newAjmanPort(), 1),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			ctx := context.Background()
+			service := ports.NewService(adapter.NewInMemoryPortsRepository())
+			storePort(ctx, t, service, newAjmanPort())
+
+			// When
+			updated, err := service.UpdatePort(ctx, tt.inputPort, tt.inputPaths)
+
+			// Then
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPort, updated)
+			}
+
+			p, err := service.GetPort(ctx, "AEAJM")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedPort, p)
+		})
+	}
+}
+
+func TestService_ListPorts(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	service := ports.NewService(adapter.NewInMemoryPortsRepository())
+	for _, id := range []string{"AEDXB", "AEAUH", "AEAJM"} {
+		p := newAjmanPort()
+		p.ID = id
+		p.Unlocs = []string{id}
+		storePort(ctx, t, service, p)
+	}
+
+	// When
+	firstPage, err := service.ListPorts(ctx, ports.ListPortsQuery{PageSize: 2})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AEAJM", "AEAUH"}, portIDs(firstPage.Ports))
+	assert.NotEmpty(t, firstPage.NextPageToken)
+
+	// When
+	secondPage, err := service.ListPorts(ctx, ports.ListPortsQuery{PageSize: 2, PageToken: firstPage.NextPageToken})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AEDXB"}, portIDs(secondPage.Ports))
+	assert.Empty(t, secondPage.NextPageToken)
+
+	// When
+	_, err = service.ListPorts(ctx, ports.ListPortsQuery{PageToken: "!invalid!"})
+
+	// Then
+	assert.ErrorIs(t, err, ports.ErrInvalidListQuery)
+
+	// When
+	_, err = service.ListPorts(ctx, ports.ListPortsQuery{PageSize: -1})
+
+	// Then
+	assert.ErrorIs(t, err, ports.ErrInvalidListQuery)
+}
+
+func TestService_GetPortHistory(t *testing.T) {
+	// Given
+	ctx := ports.WithCaller(context.Background(), "alice")
+	service := ports.NewService(adapter.NewInMemoryPortsRepository())
+	storePort(ctx, t, service, newAjmanPort())
+	_, err := service.UpdatePort(ctx, &ports.Port{ID: "AEAJM", Timezone: "Asia/Muscat"}, []string{"timezone"})
+	require.NoError(t, err)
+	require.NoError(t, service.DeletePort(ctx, "AEAJM", false))
+	require.NoError(t, service.DeletePort(context.Background(), "AEAJM", true))
+
+	// When
+	firstPage, err := service.GetPortHistory(ctx, ports.PortHistoryQuery{ID: "AEAJM", PageSize: 2})
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, firstPage.Entries, 2)
+	assert.NotEmpty(t, firstPage.NextPageToken)
+
+	created, updated := firstPage.Entries[0], firstPage.Entries[1]
+	assert.Equal(t, ports.EventCreated, created.Type)
+	assert.Nil(t, created.Previous)
+	assert.Equal(t, storedPort(newAjmanPort(), 1), *created.Port)
+	assert.Equal(t, "alice", created.Caller)
+	assert.False(t, created.Time.IsZero())
+
+	assert.Equal(t, ports.EventUpdated, updated.Type)
+	assert.Greater(t, updated.Sequence, created.Sequence)
+	assert.Equal(t, storedPort(newAjmanPort(), 1), *updated.Previous)
+	assert.Equal(t, "Asia/Muscat", updated.Port.Timezone)
+	assert.Equal(t, int64(2), updated.Port.Version)
+
+	// When
+	secondPage, err := service.GetPortHistory(ctx, ports.PortHistoryQuery{
+		ID:        "AEAJM",
+		PageSize:  2,
+		PageToken: firstPage.NextPageToken,
+	})
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, secondPage.NextPageToken)
+	require.Len(t, secondPage.Entries, 2)
+	deleted, purged := secondPage.Entries[0], secondPage.Entries[1]
+	assert.Equal(t, []ports.EventType{ports.EventDeleted, ports.EventDeleted}, []ports.EventType{deleted.Type, purged.Type})
+	assert.Equal(t, *updated.Port, *deleted.Previous)
+	assert.Nil(t, deleted.Port)
+	assert.Equal(t, storedPort(updated.Port, 3), *purged.Previous, "deleted port has the next version")
+	assert.Nil(t, purged.Port)
+	assert.Equal(t, "", purged.Caller, "caller is unknown")
+
+	// When
+	_, err = service.GetPortHistory(ctx, ports.PortHistoryQuery{ID: "AEAUH"})
+
+	// Then
+	assert.ErrorIs(t, err, ports.ErrPortNotFound)
+
+	// When
+	_, err = service.GetPortHistory(ctx, ports.PortHistoryQuery{ID: "AEAJM", PageToken: "bm90LWEtbnVtYmVy"})
+
+	// Then
+	assert.ErrorIs(t, err, ports.ErrInvalidListQuery)
+}
+
+func TestService_StreamPorts(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service := ports.NewService(adapter.NewInMemoryPortsRepository())
+
+	const portsCount = 250
+	for i := portsCount - 1; i >= 0; i-- {
+		p := newAjmanPort()
+		p.ID = testPortID(i)
+		p.Unlocs = []string{p.ID}
+		storePort(ctx, t, service, p)
+	}
+
+	// When
+	var streamed []ports.Port
+	err := service.StreamPorts(ctx, func(p ports.Port) error {
+		streamed = append(streamed, p)
+		return nil
+	})
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, streamed, portsCount)
+	for i, p := range streamed {
+		assert.Equal(t, testPortID(i), p.ID)
+	}
+
+	// When
+	var received int
+	err = service.StreamPorts(ctx, func(p ports.Port) error {
+		received++
+		cancel()
+		return nil
+	})
+
+	// Then
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, received)
+}
+
+func TestService_WatchPorts(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	service := ports.NewService(adapter.NewInMemoryPortsRepository())
+	startRevision := service.PortsRevision()
+
+	storePort(ctx, t, service, newAjmanPort())
+	updated := newAjmanPort()
+	updated.Name = "Ajman Port"
+	storePort(ctx, t, service, updated)
+	require.NoError(t, service.DeletePort(ctx, "AEAJM", false))
+
+	// When
+	events, err := watchPorts(ctx, service, startRevision, 3)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, []ports.EventType{ports.EventCreated, ports.EventUpdated, ports.EventDeleted}, eventTypes(events))
+	assert.Equal(t, storedPort(newAjmanPort(), 1), events[0].Port)
+	assert.Equal(t, storedPort(updated, 2), events[1].Port)
+	assert.Equal(t, storedPort(updated, 3), events[2].Port, "soft-deleted port has the next version")
+	assert.Equal(t, startRevision+1, events[0].Revision)
+	assert.Equal(t, service.PortsRevision(), events[2].Revision)
+
+	// When
+	resumed, err := watchPorts(ctx, service, events[0].Revision, 2)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, events[1:], resumed)
+
+	// When
+	restored := make(chan []ports.PortEvent)
+	go func() {
+		e, watchErr := watchPorts(ctx, service, events[2].Revision, 1)
+		assert.NoError(t, watchErr)
+		restored <- e
+	}()
+	_, err = service.RestorePort(ctx, "AEAJM")
+
+	// Then
+	require.NoError(t, err)
+	e := <-restored
+	assert.Equal(t, []ports.EventType{ports.EventCreated}, eventTypes(e))
+	assert.Equal(t, storedPort(updated, 4), e[0].Port, "restored port has the next version")
+
+	for _, revision := range []int64{-1, service.PortsRevision() + 1} {
+		// When
+		_, err = watchPorts(ctx, service, revision, 1)
+
+		// Then
+		assert.ErrorIs(t, err, ports.ErrInvalidWatchQuery, "revision %v", revision)
+	}
+
+	// When
+	for i := 0; i < ports.ChangeHistorySize; i++ {
+		storePort(ctx, t, service, renamedAjmanPort(i))
+	}
+	_, err = watchPorts(ctx, service, events[0].Revision, 1)
+
+	// Then
+	assert.ErrorIs(t, err, ports.ErrRevisionCompacted)
+}
+
+func TestService_WatchPortsConcurrentChanges(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	service := ports.NewService(delayedRepository{Repository: adapter.NewInMemoryPortsRepository()})
+	startRevision := service.PortsRevision()
+	const changesCount = 100
+
+	// When
+	var wg sync.WaitGroup
+	for i := 0; i < changesCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := service.StorePort(ctx, renamedAjmanPort(i))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	events, err := watchPorts(ctx, service, startRevision, changesCount)
+
+	// Then
+	require.NoError(t, err)
+	for i, e := range events {
+		assert.Equal(t, int64(i+1), e.Port.Version, "event with revision %v", e.Revision)
+	}
+}
+
+// delayedRepository returns stored ports after a random delay, so that concurrent changes return in random order.
+type delayedRepository struct {
+	ports.Repository
+}
+
+func (r delayedRepository) StorePort(ctx context.Context, p *ports.Port) (ports.Port, ports.StoreOutcome, error) {
+	stored, outcome, err := r.Repository.StorePort(ctx, p)
+	time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+	return stored, outcome, err
+}
+
+func TestService_PublishPortChanges(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := adapter.NewInMemoryPortsRepository()
+	repo.EnableOutbox()
+	service := ports.NewService(repo)
+	publisher := &fakePublisher{
+		published: make(chan ports.PortChanged, 10),
+		failures:  1,
+	}
+
+	published := make(chan error)
+	go func() {
+		published <- service.PublishPortChanges(ctx, publisher, 10*time.Millisecond)
+	}()
+
+	// When
+	storePort(ctx, t, service, newAjmanPort())
+	require.NoError(t, service.DeletePort(ctx, "AEAJM", false))
+
+	// Then
+	var events []ports.PortChanged
+	for len(events) < 2 {
+		events = append(events, <-publisher.published)
+	}
+	assert.Equal(t, []int64{1, 2}, []int64{events[0].Sequence, events[1].Sequence})
+	assert.Equal(t, []ports.EventType{ports.EventCreated, ports.EventDeleted}, []ports.EventType{
+		events[0].Type, events[1].Type,
+	})
+	assert.Equal(t, storedPort(newAjmanPort(), 1), events[0].Port)
+	assert.Equal(t, storedPort(newAjmanPort(), 2), events[1].Port)
+
+	assert.Eventually(t, func() bool {
+		pending, err := repo.PendingPortChanges(ctx, ports.OutboxBatchSize)
+		return err == nil && len(pending) == 0
+	}, time.Second, time.Millisecond, "published events should be removed from the outbox")
+
+	cancel()
+	assert.ErrorIs(t, <-published, context.Canceled)
+}
+
+// fakePublisher fails given number of times and then sends the published events to the channel.
+type fakePublisher struct {
+	published chan ports.PortChanged
+	failures  int
+}
+
+func (p *fakePublisher) PublishPortChanged(_ context.Context, e ports.PortChanged) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.published <- e
+	return nil
+}
+
+// storePort stores the port and fails the test if it is not stored.
+func storePort(ctx context.Context, t *testing.T, service ports.Service, p *ports.Port) {
+	t.Helper()
+	_, err := service.StorePort(ctx, p)
+	require.NoError(t, err)
+}
+
+// renamedAjmanPort returns Ajman port with a name unique for given number, so that storing it changes the port.
+func renamedAjmanPort(n int) *ports.Port {
+	p := newAjmanPort()
+	p.Name = fmt.Sprintf("Ajman %d", n)
+	return p
+}
+
+// watchPorts returns the given number of events after the revision.
+func watchPorts(ctx context.Context, service ports.Service, afterRevision int64, count int) ([]ports.PortEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var events []ports.PortEvent
+	err := service.WatchPorts(ctx, ports.WatchQuery{AfterRevision: afterRevision}, func(e ports.PortEvent) error {
+		events = append(events, e)
+		if len(events) == count {
+			cancel()
+		}
+		return nil
+	})
+	if errors.Is(err, context.Canceled) && len(events) == count {
+		return events, nil
+	}
+	return events, err
+}
+
+func eventTypes(events []ports.PortEvent) []ports.EventType {
+	types := make([]ports.EventType, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// testPortID returns i-th UN/LOCODE in ascending order, starting from AEAAA.
+func testPortID(i int) string {
+	return fmt.Sprintf("AE%c%c%c", 'A'+i/26/26%26, 'A'+i/26%26, 'A'+i%26)
+}
+
+func portIDs(ps []ports.Port) []string {
+	ids := make([]string, 0, len(ps))
+	for _, p := range ps {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// storedPort returns given port with the version assigned by the repository.
+func storedPort(p *ports.Port, version int64) ports.Port {
+	p.Version = version
+	return *p
+}
+
 func newAjmanPort() *ports.Port {
 	return &ports.Port{
-		ID:          "AEAJM",
-		Name:        "Ajman",
-		City:        "Ajman",
-		Country:     "United Arab Emirates",
-		Alias:       []string{"foo-alias", "bar-alias"},
-		Regions:     []string{"foo-region", "bar-region"},
-		Coordinates: []float64{55.5136433, 25.4052165},
-		Province:    "Ajman",
-		Timezone:    "Asia/Dubai",
-		Unlocs:      []string{"AEAJM"},
-		Code:        "52000",
+		ID:       "AEAJM",
+		Name:     "Ajman",
+		City:     "Ajman",
+		Country:  "United Arab Emirates",
+		Alias:    []string{"foo-alias", "bar-alias"},
+		Regions:  []string{"foo-region", "bar-region"},
+		Location: &ports.LatLng{Lat: 25.4052165, Lng: 55.5136433},
+		Province: "Ajman",
+		Timezone: "Asia/Dubai",
+		Unlocs:   []string{"AEAJM"},
+		Code:     "52000",
 	}
 }
@@ -0,0 +1,249 @@
+package ports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// DefaultSuggestLimit is a number of suggested Ports when the limit is not specified.
+	DefaultSuggestLimit = 10
+	// MaxSuggestLimit is a maximum number of suggested Ports. Larger limits are coerced to it.
+	MaxSuggestLimit = 100
+)
+
+// Weights of the Port fields in text matching scores, so that name matches rank higher than city matches etc.
+const (
+	nameWeight     = 1.0
+	aliasWeight    = 0.9
+	cityWeight     = 0.8
+	provinceWeight = 0.6
+)
+
+// TextQuery is a query for Ports with the name, aliases, city or province matching the text. Every word of the text
+// must match a word of the Port exactly, with typos allowed by MaxTermEdits or, if it is the last word, as a prefix.
+// Letter case and diacritics are ignored.
+type TextQuery struct {
+	Text string
+	// Limit is a maximum number of Ports to suggest. DefaultSuggestLimit is used if zero.
+	Limit int
+}
+
+// ScoredPort is a Port found by TextQuery.
+type ScoredPort struct {
+	Port Port
+	// Score in range (0, 1] tells how well the Port matches the query. It is 1 for exact matches of the name words.
+	Score float64
+}
+
+// TextMatcher scores Ports against the terms of the query text.
+type TextMatcher struct {
+	// Terms are folded words of the query text. The last term is matched also as a prefix.
+	Terms []string
+}
+
+// Matcher returns the matcher of the query text.
+func (q TextQuery) Matcher() TextMatcher {
+	return TextMatcher{Terms: TextTerms(q.Text)}
+}
+
+// normalize validates the query and applies the default limit.
+func (q TextQuery) normalize() (TextQuery, error) {
+	var violations []FieldViolation
+	if len(TextTerms(q.Text)) == 0 {
+		violations = append(violations, FieldViolation{
+			Field:       "query",
+			Description: "query must contain a letter or digit",
+		})
+	}
+	if q.Limit < 0 {
+		violations = append(violations, FieldViolation{
+			Field:       "limit",
+			Description: fmt.Sprintf("limit %v must not be negative", q.Limit),
+		})
+	}
+	if len(violations) > 0 {
+		return TextQuery{}, newValidationError(ErrInvalidTextQuery, violations...)
+	}
+
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultSuggestLimit
+	case q.Limit > MaxSuggestLimit:
+		q.Limit = MaxSuggestLimit
+	}
+	return q, nil
+}
+
+// textFolder decomposes characters, so that diacritics can be removed, and removes them. Spacing diacritics,
+// like the cedilla in "Abu Z¸aby", are removed first, as they decompose to a space and a diacritic.
+var textFolder = transform.Chain(runes.Remove(runes.In(unicode.Sk)), norm.NFKD, runes.Remove(runes.In(unicode.Mn)))
+
+// letterFolder replaces letters that are not decomposed to a base letter and a diacritic.
+var letterFolder = strings.NewReplacer("ł", "l", "ø", "o", "đ", "d", "ħ", "h", "ı", "i", "æ", "ae", "œ", "oe", "ß", "ss")
+
+// FoldText returns the text in lower case without diacritics.
+func FoldText(text string) string {
+	// Lower case is applied first, as it can add diacritics, e.g. to "İ"
+	lower := strings.ToLower(text)
+	folded, _, err := transform.String(textFolder, lower)
+	if err != nil {
+		// Transformations do not fail on any input, but the text is still usable without folding
+		folded = lower
+	}
+	return letterFolder.Replace(folded)
+}
+
+// TextTerms splits the folded text into words of letters and digits.
+func TextTerms(text string) []string {
+	return strings.FieldsFunc(FoldText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// PortTerms returns the distinct terms of the Port text fields, in undefined order.
+func PortTerms(p Port) []string {
+	seen := make(map[string]struct{})
+	var result []string
+	for _, text := range append([]string{p.Name, p.City, p.Province}, p.Alias...) {
+		for _, term := range TextTerms(text) {
+			if _, ok := seen[term]; !ok {
+				seen[term] = struct{}{}
+				result = append(result, term)
+			}
+		}
+	}
+	return result
+}
+
+// MaxTermEdits returns the number of typos allowed in the query term: none in terms shorter than 3 letters,
+// one in terms shorter than 7 letters and two in longer ones. A typo is a changed, missing or additional letter,
+// or two swapped adjacent letters.
+func MaxTermEdits(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n < 3:
+		return 0
+	case n < 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Score returns the score of the Port and reports whether all query terms match it. The score is the mean of
+// the best matches of the query terms, weighted by the Port field they match.
+func (m TextMatcher) Score(p Port) (float64, bool) {
+	if len(m.Terms) == 0 {
+		return 0, false
+	}
+
+	type weightedTerms struct {
+		terms  []string
+		weight float64
+	}
+	fields := []weightedTerms{
+		{terms: TextTerms(p.Name), weight: nameWeight},
+		{terms: TextTerms(p.City), weight: cityWeight},
+		{terms: TextTerms(p.Province), weight: provinceWeight},
+	}
+	for _, alias := range p.Alias {
+		fields = append(fields, weightedTerms{terms: TextTerms(alias), weight: aliasWeight})
+	}
+
+	var sum float64
+	for i, queryTerm := range m.Terms {
+		var best float64
+		for _, field := range fields {
+			for _, portTerm := range field.terms {
+				if s := m.termScore(i, queryTerm, portTerm) * field.weight; s > best {
+					best = s
+				}
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		sum += best
+	}
+	return sum / float64(len(m.Terms)), true
+}
+
+// IsPrefix reports whether the ith query term can match a prefix of a Port term.
+func (m TextMatcher) IsPrefix(i int) bool {
+	return i == len(m.Terms)-1
+}
+
+// termScore scores the match of the ith query term with the Port term. Exact matches score 1, prefixes score
+// at least 0.5 depending on the length of the matched part, and the matches with typos score less with every typo.
+// It is 0 if the terms do not match.
+func (m TextMatcher) termScore(i int, queryTerm, portTerm string) float64 {
+	if queryTerm == portTerm {
+		return 1
+	}
+
+	q, t := []rune(queryTerm), []rune(portTerm)
+	var score float64
+	if m.IsPrefix(i) && strings.HasPrefix(portTerm, queryTerm) {
+		score = 0.5 + 0.5*float64(len(q))/float64(len(t))
+	}
+	if d := EditDistance(queryTerm, portTerm); d <= MaxTermEdits(queryTerm) {
+		if s := 1 - float64(d)/float64(len(q)+1); s > score {
+			score = s
+		}
+	}
+	return score
+}
+
+// EditDistance returns the number of changed, missing, additional or swapped adjacent letters between the terms,
+// that is their optimal string alignment distance.
+func EditDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// Rows of distances between the prefixes of s and t, for the current and two previous prefixes of s
+	prev2, prev, cur := make([]int, len(t)+1), make([]int, len(t)+1), make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = minInt(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(t)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
+
+// SortScoredPorts sorts the Ports by descending score and ascending ID.
+func SortScoredPorts(ps []ScoredPort) {
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Score != ps[j].Score {
+			return ps[i].Score > ps[j].Score
+		}
+		return ps[i].Port.ID < ps[j].Port.ID
+	})
+}
@@ -0,0 +1,122 @@
+package ports_test
+
+import (
+	"testing"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextTerms(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "spacing cedilla and brackets given",
+			text:     "Abu Z¸aby [Abu Dhabi]",
+			expected: []string{"abu", "zaby", "abu", "dhabi"},
+		}, {
+			name:     "combining and precomposed diacritics given",
+			text:     "Ålesund, Gdańsk, Świnoujście",
+			expected: []string{"alesund", "gdansk", "swinoujscie"},
+		}, {
+			name:     "letters without decomposition given",
+			text:     "Łeba Ærøskøbing İzmir",
+			expected: []string{"leba", "aeroskobing", "izmir"},
+		}, {
+			name:     "punctuation only given",
+			text:     " - ' ",
+			expected: []string{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ElementsMatch(t, tt.expected, ports.TextTerms(tt.text))
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tt := range []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "ali", b: "ali", expected: 0},
+		{a: "aly", b: "ali", expected: 1},
+		{a: "dubia", b: "dubai", expected: 1},
+		{a: "jebl", b: "jebel", expected: 1},
+		{a: "rotterdma", b: "rotterdam", expected: 1},
+		{a: "gdnask", b: "gdansk", expected: 1},
+		{a: "hamburg", b: "hambrug", expected: 1},
+		{a: "ca", b: "abc", expected: 3},
+		{a: "", b: "abc", expected: 3},
+	} {
+		t.Run(tt.a+"-"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ports.EditDistance(tt.a, tt.b))
+			assert.Equal(t, tt.expected, ports.EditDistance(tt.b, tt.a))
+		})
+	}
+}
+
+func TestTextMatcher_Score(t *testing.T) {
+	port := ports.Port{
+		Name:     "Jebel Ali",
+		City:     "Dubai",
+		Province: "Abu Z¸aby",
+		Alias:    []string{"Mina Jebel Ali"},
+	}
+
+	for _, tt := range []struct {
+		name          string
+		text          string
+		expectedScore float64
+		expectedOK    bool
+	}{
+		{
+			name:          "exact name given",
+			text:          "jebel ali",
+			expectedScore: 1,
+			expectedOK:    true,
+		}, {
+			name:          "alias word given",
+			text:          "Mina",
+			expectedScore: 0.9,
+			expectedOK:    true,
+		}, {
+			name:          "name with a typo given",
+			text:          "Jebel Aly",
+			expectedScore: (1 + 0.75) / 2,
+			expectedOK:    true,
+		}, {
+			name:          "province without diacritics given",
+			text:          "zaby",
+			expectedScore: 0.6,
+			expectedOK:    true,
+		}, {
+			name:          "incomplete last word given",
+			text:          "jeb",
+			expectedScore: 0.5 + 0.5*3/5,
+			expectedOK:    true,
+		}, {
+			name:       "incomplete word that is not the last given",
+			text:       "jeb ali",
+			expectedOK: false,
+		}, {
+			name:       "word not in the port given",
+			text:       "jebel dhanna",
+			expectedOK: false,
+		}, {
+			name:       "short word that is not the last given",
+			text:       "al dubai",
+			expectedOK: false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := ports.TextQuery{Text: tt.text}.Matcher().Score(port)
+
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.InDelta(t, tt.expectedScore, score, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package portssvc
+
+import (
+	"context"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"github.com/danielfurman/ports-microservices/internal/portssvc/portsgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// callerUnaryInterceptor passes the caller identity from the request metadata to the handler context.
+func callerUnaryInterceptor(
+	ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return handler(withMetadataCaller(ctx), req)
+}
+
+// callerStreamInterceptor passes the caller identity from the request metadata to the stream context.
+func callerStreamInterceptor(
+	srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	return handler(srv, callerServerStream{
+		ServerStream: stream,
+		ctx:          withMetadataCaller(stream.Context()),
+	})
+}
+
+type callerServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s callerServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// withMetadataCaller returns the context with the caller from the incoming metadata. The last value is used if the
+// key is repeated. The context is returned unchanged if there is no caller in the metadata.
+func withMetadataCaller(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(portsgrpc.CallerMetadataKey)
+	if len(values) == 0 {
+		return ctx
+	}
+	return ports.WithCaller(ctx, values[len(values)-1])
+}
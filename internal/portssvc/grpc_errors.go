@@ -0,0 +1,61 @@
+package portssvc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/danielfurman/ports-microservices/internal/portssvc/domain/ports"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError translates the error returned by Ports service to gRPC status error.
+// Validation errors are returned with google.rpc.BadRequest details listing the invalid fields.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		validationErr *ports.ValidationError
+		notFoundErr   *ports.NotFoundError
+		conflictErr   *ports.ConflictError
+		duplicateErr  *ports.DuplicateUnlocError
+		compactedErr  *ports.RevisionCompactedError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		return invalidArgumentStatus(err, validationErr).Err()
+	case errors.As(err, &notFoundErr):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.As(err, &conflictErr):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.As(err, &duplicateErr):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.As(err, &compactedErr):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func invalidArgumentStatus(err error, validationErr *ports.ValidationError) *status.Status {
+	st := status.New(codes.InvalidArgument, err.Error())
+
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range validationErr.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	withDetails, detailsErr := st.WithDetails(badRequest)
+	if detailsErr != nil {
+		return st
+	}
+	return withDetails
+}
@@ -1,10 +1,14 @@
-// Package portssvc implement Ports that exposes a gRPC API that allows to store and list Ports in persistence layer.
+// Package portssvc implement Ports that exposes a gRPC API that allows to store, get and list Ports in persistence layer.
 package portssvc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"time"
 
 	"github.com/danielfurman/ports-microservices/internal/logs"
 	"github.com/danielfurman/ports-microservices/internal/portssvc/adapter"
@@ -13,45 +17,231 @@ import (
 	emptypb "github.com/golang/protobuf/ptypes/empty"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// Repository types supported by GRPCServer.
+const (
+	RepositoryInMemory = "in-memory"
+	RepositoryPostgres = "postgres"
+	RepositoryBolt     = "bolt"
+)
+
+// Publisher types supported by GRPCServer.
+const (
+	PublisherNATS   = "nats"
+	PublisherFile   = "file"
+	PublisherStdout = "stdout"
+)
+
+// defaultOutboxPollInterval is used when Config.OutboxPollInterval is not set.
+const defaultOutboxPollInterval = time.Second
+
 // GRPCServer is a gRPC server for Ports domain service.
 type GRPCServer struct {
 	cfg Config
 
 	portsgrpc.UnimplementedPortServiceServer
 	service ports.Service
-	log     *logrus.Entry
+	// repoCloser closes the repository of the service. It is nil if the repository does not need closing.
+	repoCloser io.Closer
+	// publisher publishes port changes from the repository outbox. It is nil if publishing is disabled.
+	publisher       ports.Publisher
+	publisherCloser io.Closer
+	log             *logrus.Entry
 
 	listenerAddress net.Addr
 	listenerReady   chan struct{}
+	// stopping is closed when the server starts to stop, so that the long-lived streams end.
+	stopping chan struct{}
 }
 
 // Config is a configuration for GRPCServer.
 type Config struct {
 	// GRPCServerAddress is a TCP address of the server. Env var: GRPC_SERVER_ADDRESS. Default: ":9090".
 	GRPCServerAddress string `env:"GRPC_SERVER_ADDRESS" envDefault:":9090"`
+	// Repository is a type of the Ports repository: "in-memory", "postgres" or "bolt". Env var: REPOSITORY.
+	// Default: "in-memory".
+	Repository string `env:"REPOSITORY" envDefault:"in-memory"`
+	// WALDir is a directory of the write-ahead log and snapshots of "in-memory" repository. The repository is not
+	// persisted if it is empty. Env var: WAL_DIR.
+	WALDir string `env:"WAL_DIR"`
+	// SnapshotInterval is an interval of compacting the write-ahead log of "in-memory" repository into a snapshot.
+	// Env var: SNAPSHOT_INTERVAL. Default: "5m".
+	SnapshotInterval time.Duration `env:"SNAPSHOT_INTERVAL" envDefault:"5m"`
+	// WALSync enables syncing the write-ahead log to disk after every change. Env var: WAL_SYNC. Default: "true".
+	WALSync bool `env:"WAL_SYNC" envDefault:"true"`
+	// PostgresURL is a connection URL of PostgreSQL database used by "postgres" repository. Env var: POSTGRES_URL.
+	PostgresURL string `env:"POSTGRES_URL"`
+	// DataDir is a directory of the database file used by "bolt" repository. Env var: DATA_DIR. Default: "data".
+	DataDir string `env:"DATA_DIR" envDefault:"data"`
+	// Publisher is a destination of port change events: "nats", "file" or "stdout". Events are recorded in the outbox
+	// of the repository and published only if it is set. Env var: PUBLISHER.
+	Publisher string `env:"PUBLISHER"`
+	// PublisherFile is a path of the file that "file" publisher appends events to. Env var: PUBLISHER_FILE.
+	// Default: "port-changes.jsonl".
+	PublisherFile string `env:"PUBLISHER_FILE" envDefault:"port-changes.jsonl"`
+	// NATSURL is a URL of NATS server used by "nats" publisher. Env var: NATS_URL. Default: "nats://localhost:4222".
+	NATSURL string `env:"NATS_URL" envDefault:"nats://localhost:4222"`
+	// NATSSubject is a subject of messages of "nats" publisher. Env var: NATS_SUBJECT. Default: "ports.changed".
+	NATSSubject string `env:"NATS_SUBJECT" envDefault:"ports.changed"`
+	// OutboxPollInterval is an interval of polling the outbox for events to publish. Env var: OUTBOX_POLL_INTERVAL.
+	// Default: "1s".
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
 }
 
 // NewServer creates new GRPCServer with given configuration.
-func NewServer(cfg Config) *GRPCServer {
+// The repository is set up on creation, so pending database migrations are applied here.
+func NewServer(ctx context.Context, cfg Config) (*GRPCServer, error) {
 	logs.Configure()
 	log := logs.NewLogger("ports-server")
 	log.WithField("config", fmt.Sprintf("%+v", cfg)).Debug("Creating ports server")
 
+	publisher, publisherCloser, err := newPublisher(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create %v publisher: %w", cfg.Publisher, err)
+	}
+
+	repo, repoCloser, err := newRepository(ctx, cfg)
+	if err != nil {
+		if publisherCloser != nil {
+			_ = publisherCloser.Close()
+		}
+		return nil, fmt.Errorf("create %v repository: %w", cfg.Repository, err)
+	}
+
 	return &GRPCServer{
-		cfg: cfg,
-		// TODO(dfurman): implement and use adapter.NewPostgresRepository()
-		service:       ports.NewService(adapter.NewInMemoryPortsRepository()),
-		log:           log,
-		listenerReady: make(chan struct{}),
+		cfg:             cfg,
+		service:         ports.NewService(repo),
+		repoCloser:      repoCloser,
+		publisher:       publisher,
+		publisherCloser: publisherCloser,
+		log:             log,
+		listenerReady:   make(chan struct{}),
+		stopping:        make(chan struct{}),
+	}, nil
+}
+
+// outboxRepository is a repository that can record port changes in its outbox.
+type outboxRepository interface {
+	ports.Repository
+	EnableOutbox()
+}
+
+// newRepository creates the configured repository. Its outbox is enabled if a publisher is configured.
+func newRepository(ctx context.Context, cfg Config) (ports.Repository, io.Closer, error) {
+	var (
+		repo   outboxRepository
+		closer io.Closer
+	)
+	switch cfg.Repository {
+	case RepositoryInMemory, "":
+		if cfg.WALDir == "" {
+			repo = adapter.NewInMemoryPortsRepository()
+			break
+		}
+		r, err := adapter.NewInMemoryPortsRepositoryWithWAL(adapter.WALOptions{
+			Dir:              cfg.WALDir,
+			SnapshotInterval: cfg.SnapshotInterval,
+			Sync:             cfg.WALSync,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		repo, closer = r, r
+	case RepositoryPostgres:
+		if cfg.PostgresURL == "" {
+			return nil, nil, errors.New("PostgreSQL URL is required")
+		}
+		r, err := adapter.NewPostgresPortsRepository(ctx, cfg.PostgresURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		repo, closer = r, r
+	case RepositoryBolt:
+		if cfg.DataDir == "" {
+			return nil, nil, errors.New("data directory is required")
+		}
+		r, err := adapter.NewBoltPortsRepository(cfg.DataDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		repo, closer = r, r
+	default:
+		return nil, nil, fmt.Errorf("unknown repository type %q", cfg.Repository)
+	}
+
+	if cfg.Publisher != "" {
+		repo.EnableOutbox()
+	}
+	return repo, closer, nil
+}
+
+// newPublisher creates the configured publisher. It returns nil publisher if publishing is disabled.
+func newPublisher(cfg Config) (ports.Publisher, io.Closer, error) {
+	switch cfg.Publisher {
+	case "":
+		return nil, nil, nil
+	case PublisherNATS:
+		p, err := adapter.NewNATSPublisher(cfg.NATSURL, cfg.NATSSubject)
+		if err != nil {
+			return nil, nil, err
+		}
+		return p, p, nil
+	case PublisherFile:
+		p, err := adapter.NewFilePublisher(cfg.PublisherFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return p, p, nil
+	case PublisherStdout:
+		return adapter.NewWriterPublisher(os.Stdout), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown publisher type %q", cfg.Publisher)
 	}
 }
 
 // Serve starts the gRPC Ports server. The server is gracefully stopped on context cancel/timeout.
+// This function is meant to be called only once, because it closes the repository when the server stops.
 // TODO(dfurman): support request/response logging.
-func (s *GRPCServer) Serve(ctx context.Context) error {
-	grpcServer := grpc.NewServer()
+func (s *GRPCServer) Serve(ctx context.Context) (err error) {
+	defer func() {
+		if s.repoCloser == nil {
+			return
+		}
+		cErr := s.repoCloser.Close()
+		if cErr != nil && err == nil {
+			err = fmt.Errorf("failed to close repository: %w", cErr)
+		}
+	}()
+
+	if s.publisher != nil {
+		// Publishing is stopped before the repository is closed
+		publishCtx, stopPublishing := context.WithCancel(ctx)
+		published := make(chan struct{})
+		go func() {
+			defer close(published)
+			s.publishPortChanges(publishCtx)
+		}()
+		defer func() {
+			stopPublishing()
+			<-published
+			if s.publisherCloser == nil {
+				return
+			}
+			cErr := s.publisherCloser.Close()
+			if cErr != nil && err == nil {
+				err = fmt.Errorf("failed to close publisher: %w", cErr)
+			}
+		}()
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(callerUnaryInterceptor),
+		grpc.StreamInterceptor(callerStreamInterceptor),
+	)
 	portsgrpc.RegisterPortServiceServer(grpcServer, s)
 
 	go s.gracefulStopOnCancel(ctx, grpcServer)
@@ -71,10 +261,25 @@ func (s *GRPCServer) Serve(ctx context.Context) error {
 	return err
 }
 
-// gracefulStopOnCancel stops the server on context cancel/timeout.
+// publishPortChanges publishes port changes from the repository outbox until the context is done.
+func (s *GRPCServer) publishPortChanges(ctx context.Context) {
+	interval := s.cfg.OutboxPollInterval
+	if interval <= 0 {
+		interval = defaultOutboxPollInterval
+	}
+
+	err := s.service.PublishPortChanges(ctx, s.publisher, interval)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		s.log.WithError(err).Error("Publishing port changes stopped")
+	}
+}
+
+// gracefulStopOnCancel stops the server on context cancel/timeout. Watch streams are ended first, because they
+// would block the graceful stop until the clients cancel them.
 func (s *GRPCServer) gracefulStopOnCancel(ctx context.Context, grpcServer *grpc.Server) {
 	<-ctx.Done()
 	s.log.Debug("Stopping the gRPC server")
+	close(s.stopping)
 	grpcServer.GracefulStop()
 }
 
@@ -89,39 +294,332 @@ func (s *GRPCServer) markListenerReady() {
 }
 
 // StorePort handles the store port request.
-func (s *GRPCServer) StorePort(ctx context.Context, req *portsgrpc.StorePortRequest) (*emptypb.Empty, error) {
-	err := s.service.StorePort(
-		ctx,
-		portPayloadToDomain(req.GetPort()),
-	)
-	return &emptypb.Empty{}, err
+func (s *GRPCServer) StorePort(
+	ctx context.Context, req *portsgrpc.StorePortRequest,
+) (*portsgrpc.StorePortResponse, error) {
+	outcome, err := s.storePort(ctx, req.GetPort())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &portsgrpc.StorePortResponse{Outcome: domainStoreOutcomeToPayload(outcome)}, nil
+}
+
+func (s *GRPCServer) storePort(ctx context.Context, payload *portsgrpc.Port) (ports.StoreOutcome, error) {
+	p, err := portPayloadToDomain(payload)
+	if err != nil {
+		return 0, err
+	}
+	return s.service.StorePort(ctx, p)
+}
+
+// StorePorts handles the stream of store port requests. Each port is stored independently, so invalid ports
+// and storage failures do not stop the stream. The summary is sent when the client closes the stream.
+func (s *GRPCServer) StorePorts(stream portsgrpc.PortService_StorePortsServer) error {
+	summary := &portsgrpc.StorePortsResponse{}
+	for index := int64(0); ; index++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			s.log.WithFields(logrus.Fields{
+				"stored-count":     summary.StoredCount,
+				"created-count":    summary.CreatedCount,
+				"updated-count":    summary.UpdatedCount,
+				"unchanged-count":  summary.UnchangedCount,
+				"rejected-count":   summary.RejectedCount,
+				"failed-count":     summary.FailedCount,
+				"errors-truncated": summary.ErrorsTruncated,
+			}).Debug("Stored ports stream")
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+
+		outcome, err := s.storePort(stream.Context(), req.GetPort())
+		if err == nil {
+			summary.StoredCount++
+			switch outcome {
+			case ports.StoreCreated:
+				summary.CreatedCount++
+			case ports.StoreUpdated:
+				summary.UpdatedCount++
+			case ports.StoreUnchanged:
+				summary.UnchangedCount++
+			}
+			continue
+		}
+
+		code := status.Code(statusError(err))
+		if code == codes.InvalidArgument || code == codes.AlreadyExists {
+			summary.RejectedCount++
+		} else {
+			summary.FailedCount++
+		}
+		if len(summary.Errors) == portsgrpc.StorePortsMaxErrors {
+			summary.ErrorsTruncated = true
+			continue
+		}
+		summary.Errors = append(summary.Errors, &portsgrpc.StorePortError{
+			Index:   index,
+			PortId:  req.GetPort().GetId(),
+			Code:    int32(code),
+			Message: err.Error(),
+		})
+	}
+}
+
+// GetPort handles the get port request.
+func (s *GRPCServer) GetPort(ctx context.Context, req *portsgrpc.GetPortRequest) (*portsgrpc.Port, error) {
+	p, err := s.service.GetPort(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return domainPortToPayload(p), nil
+}
+
+// LookupPort handles the look up port request.
+func (s *GRPCServer) LookupPort(ctx context.Context, req *portsgrpc.LookupPortRequest) (*portsgrpc.Port, error) {
+	p, err := s.service.LookupPort(ctx, req.GetUnloc())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return domainPortToPayload(p), nil
+}
+
+// UpdatePort handles the update port request.
+func (s *GRPCServer) UpdatePort(ctx context.Context, req *portsgrpc.UpdatePortRequest) (*portsgrpc.Port, error) {
+	update, err := portPayloadToDomain(req.GetPort())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	p, err := s.service.UpdatePort(ctx, update, req.GetUpdateMask().GetPaths())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return domainPortToPayload(p), nil
+}
+
+// DeletePort handles the delete port request.
+func (s *GRPCServer) DeletePort(ctx context.Context, req *portsgrpc.DeletePortRequest) (*emptypb.Empty, error) {
+	err := s.service.DeletePort(ctx, req.GetId(), req.GetPurge())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// RestorePort handles the restore port request.
+func (s *GRPCServer) RestorePort(ctx context.Context, req *portsgrpc.RestorePortRequest) (*portsgrpc.Port, error) {
+	p, err := s.service.RestorePort(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return domainPortToPayload(p), nil
 }
 
 // ListPorts handles the list ports request.
-func (s *GRPCServer) ListPorts(ctx context.Context, _ *emptypb.Empty) (*portsgrpc.ListPortsResponse, error) {
-	p, err := s.service.ListPorts(ctx)
+func (s *GRPCServer) ListPorts(ctx context.Context, req *portsgrpc.ListPortsRequest) (*portsgrpc.ListPortsResponse, error) {
+	result, err := s.service.ListPorts(ctx, ports.ListPortsQuery{
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
 	return &portsgrpc.ListPortsResponse{
-		Ports: domainPortsToPayload(p),
-	}, err
+		Ports:         domainPortsToPayload(result.Ports),
+		NextPageToken: result.NextPageToken,
+	}, nil
+}
+
+// SearchPorts handles the search ports request.
+func (s *GRPCServer) SearchPorts(
+	ctx context.Context, req *portsgrpc.SearchPortsRequest,
+) (*portsgrpc.SearchPortsResponse, error) {
+	result, err := s.service.SearchPorts(ctx, ports.SearchPortsQuery{
+		Filter: ports.PortFilter{
+			Country:    req.GetCountry(),
+			Province:   req.GetProvince(),
+			Region:     req.GetRegion(),
+			Timezone:   req.GetTimezone(),
+			Code:       req.GetCode(),
+			NamePrefix: req.GetNamePrefix(),
+		},
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &portsgrpc.SearchPortsResponse{
+		Ports:         domainPortsToPayload(result.Ports),
+		NextPageToken: result.NextPageToken,
+	}, nil
+}
+
+// SuggestPorts handles the suggest ports request.
+func (s *GRPCServer) SuggestPorts(
+	ctx context.Context, req *portsgrpc.SuggestPortsRequest,
+) (*portsgrpc.SuggestPortsResponse, error) {
+	suggested, err := s.service.SuggestPorts(ctx, ports.TextQuery{
+		Text:  req.GetQuery(),
+		Limit: int(req.GetLimit()),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &portsgrpc.SuggestPortsResponse{
+		Ports: make([]*portsgrpc.ScoredPort, 0, len(suggested)),
+	}
+	for _, sp := range suggested {
+		resp.Ports = append(resp.Ports, &portsgrpc.ScoredPort{
+			Port:  domainPortToPayload(sp.Port),
+			Score: sp.Score,
+		})
+	}
+	return resp, nil
+}
+
+// FindNearbyPorts handles the find nearby ports request.
+func (s *GRPCServer) FindNearbyPorts(
+	ctx context.Context, req *portsgrpc.FindNearbyPortsRequest,
+) (*portsgrpc.FindNearbyPortsResponse, error) {
+	nearby, err := s.service.FindNearbyPorts(ctx, ports.NearbyQuery{
+		Location: latLngPayloadToDomain(req.GetLocation()),
+		RadiusKm: req.GetRadiusKm(),
+		Limit:    int(req.GetLimit()),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &portsgrpc.FindNearbyPortsResponse{
+		Ports: make([]*portsgrpc.NearbyPort, 0, len(nearby)),
+	}
+	for _, n := range nearby {
+		resp.Ports = append(resp.Ports, &portsgrpc.NearbyPort{
+			Port:       domainPortToPayload(n.Port),
+			DistanceKm: n.DistanceKm,
+		})
+	}
+	return resp, nil
+}
+
+// StreamPorts handles the stream ports request. Sending blocks while the client does not keep up with receiving,
+// so reading from the repository is paced by the gRPC flow control.
+func (s *GRPCServer) StreamPorts(_ *portsgrpc.StreamPortsRequest, stream portsgrpc.PortService_StreamPortsServer) error {
+	err := s.service.StreamPorts(stream.Context(), func(p ports.Port) error {
+		return stream.Send(domainPortToPayload(p))
+	})
+	if _, ok := status.FromError(err); ok {
+		// Send errors are already gRPC status errors
+		return err
+	}
+	return statusError(err)
+}
+
+// WatchPorts handles the watch ports request. Events are sent until the client cancels the stream, the server stops
+// or the client does not keep up with the changes. The stream of the stopping server ends with Unavailable code,
+// so that the client can resume watching on another server. The header is sent once the revision to watch after
+// is known, so that the client can wait for it before making changes it expects to receive.
+func (s *GRPCServer) WatchPorts(req *portsgrpc.WatchPortsRequest, stream portsgrpc.PortService_WatchPortsServer) error {
+	q := ports.WatchQuery{AfterRevision: req.AfterRevision}
+	if q.AfterRevision == 0 {
+		q.AfterRevision = s.service.PortsRevision()
+	}
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := s.service.WatchPorts(ctx, q, func(e ports.PortEvent) error {
+		return stream.Send(domainPortEventToPayload(e))
+	})
+	select {
+	case <-s.stopping:
+		return status.Error(codes.Unavailable, "server is stopping")
+	default:
+	}
+	if _, ok := status.FromError(err); ok {
+		// Send errors are already gRPC status errors
+		return err
+	}
+	return statusError(err)
+}
+
+// GetPortHistory handles the get port history request.
+func (s *GRPCServer) GetPortHistory(
+	ctx context.Context, req *portsgrpc.GetPortHistoryRequest,
+) (*portsgrpc.GetPortHistoryResponse, error) {
+	result, err := s.service.GetPortHistory(ctx, ports.PortHistoryQuery{
+		ID:        req.GetId(),
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &portsgrpc.GetPortHistoryResponse{
+		Entries:       make([]*portsgrpc.PortHistoryEntry, 0, len(result.Entries)),
+		NextPageToken: result.NextPageToken,
+	}
+	for _, e := range result.Entries {
+		resp.Entries = append(resp.Entries, domainHistoryEntryToPayload(e))
+	}
+	return resp, nil
 }
 
-func portPayloadToDomain(p *portsgrpc.Port) *ports.Port {
+// portPayloadToDomain converts the port payload. The location is read from the legacy coordinates if it is unset.
+func portPayloadToDomain(p *portsgrpc.Port) (*ports.Port, error) {
 	if p == nil {
-		return nil
+		return nil, nil
+	}
+
+	location := latLngPayloadToDomain(p.Location)
+	if location == nil {
+		var err error
+		location, err = ports.LatLngFromCoordinates(p.Coordinates) //nolint:staticcheck // legacy field is supported
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &ports.Port{
-		ID:          p.Id,
-		Name:        p.Name,
-		City:        p.City,
-		Country:     p.Country,
-		Alias:       p.Alias,
-		Regions:     p.Regions,
-		Coordinates: p.Coordinates,
-		Province:    p.Province,
-		Timezone:    p.Timezone,
-		Unlocs:      p.Unlocs,
-		Code:        p.Code,
+		ID:       p.Id,
+		Name:     p.Name,
+		City:     p.City,
+		Country:  p.Country,
+		Alias:    p.Alias,
+		Regions:  p.Regions,
+		Location: location,
+		Province: p.Province,
+		Timezone: p.Timezone,
+		Unlocs:   p.Unlocs,
+		Code:     p.Code,
+		Version:  p.Version,
+	}, nil
+}
+
+func latLngPayloadToDomain(l *portsgrpc.LatLng) *ports.LatLng {
+	if l == nil {
+		return nil
+	}
+	return &ports.LatLng{
+		Lat: l.Latitude,
+		Lng: l.Longitude,
 	}
 }
 
@@ -133,6 +631,7 @@ func domainPortsToPayload(ports []ports.Port) []*portsgrpc.Port {
 	return result
 }
 
+// domainPortToPayload converts the port to payload with both the location and the legacy coordinates.
 func domainPortToPayload(p ports.Port) *portsgrpc.Port {
 	return &portsgrpc.Port{
 		Id:          p.ID,
@@ -141,10 +640,72 @@ func domainPortToPayload(p ports.Port) *portsgrpc.Port {
 		Country:     p.Country,
 		Alias:       p.Alias,
 		Regions:     p.Regions,
-		Coordinates: p.Coordinates,
+		Coordinates: p.Location.Coordinates(),
+		Location:    latLngDomainToPayload(p.Location),
 		Province:    p.Province,
 		Timezone:    p.Timezone,
 		Unlocs:      p.Unlocs,
 		Code:        p.Code,
+		Version:     p.Version,
+	}
+}
+
+func latLngDomainToPayload(l *ports.LatLng) *portsgrpc.LatLng {
+	if l == nil {
+		return nil
+	}
+	return &portsgrpc.LatLng{
+		Latitude:  l.Lat,
+		Longitude: l.Lng,
+	}
+}
+
+func domainPortEventToPayload(e ports.PortEvent) *portsgrpc.PortEvent {
+	return &portsgrpc.PortEvent{
+		Revision: e.Revision,
+		Type:     domainEventTypeToPayload(e.Type),
+		Port:     domainPortToPayload(e.Port),
+	}
+}
+
+func domainHistoryEntryToPayload(e ports.HistoryEntry) *portsgrpc.PortHistoryEntry {
+	entry := &portsgrpc.PortHistoryEntry{
+		Sequence: e.Sequence,
+		Type:     domainEventTypeToPayload(e.Type),
+		Time:     timestamppb.New(e.Time),
+		Caller:   e.Caller,
+	}
+	if e.Previous != nil {
+		entry.Previous = domainPortToPayload(*e.Previous)
+	}
+	if e.Port != nil {
+		entry.Port = domainPortToPayload(*e.Port)
+	}
+	return entry
+}
+
+func domainEventTypeToPayload(t ports.EventType) portsgrpc.PortEvent_Type {
+	switch t {
+	case ports.EventCreated:
+		return portsgrpc.PortEvent_CREATED
+	case ports.EventUpdated:
+		return portsgrpc.PortEvent_UPDATED
+	case ports.EventDeleted:
+		return portsgrpc.PortEvent_DELETED
+	default:
+		return portsgrpc.PortEvent_TYPE_UNSPECIFIED
+	}
+}
+
+func domainStoreOutcomeToPayload(o ports.StoreOutcome) portsgrpc.StorePortResponse_Outcome {
+	switch o {
+	case ports.StoreCreated:
+		return portsgrpc.StorePortResponse_CREATED
+	case ports.StoreUpdated:
+		return portsgrpc.StorePortResponse_UPDATED
+	case ports.StoreUnchanged:
+		return portsgrpc.StorePortResponse_UNCHANGED
+	default:
+		return portsgrpc.StorePortResponse_OUTCOME_UNSPECIFIED
 	}
 }
@@ -2,85 +2,1199 @@ package portssvc_test
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/danielfurman/ports-microservices/internal/portsclient"
 	"github.com/danielfurman/ports-microservices/internal/portssvc"
 	"github.com/danielfurman/ports-microservices/internal/portssvc/portsgrpc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestPortsServer_StorePorts(t *testing.T) {
-	for _, tt := range []struct {
-		name      string
-		inputPort *portsgrpc.Port
-		// TODO(dfurman): verify gRPC error codes
-		expectedError bool
-		expectedPorts []*portsgrpc.Port
-	}{
-		{
-			name:          "nil port given",
-			expectedError: true,
-		}, {
-			name:          "empty port given",
-			expectedError: true,
-		}, {
-			name:      "valid port given",
-			inputPort: newAjmanPort(),
-			expectedPorts: []*portsgrpc.Port{
-				newAjmanPort(),
-			},
-		}, {
-			name: "port with empty ID given",
-			inputPort: func() *portsgrpc.Port {
-				p := newAjmanPort()
-				p.Id = ""
-				return p
-			}(),
-			expectedError: true,
-		}, {
-			name: "port with empty name given",
-			inputPort: func() *portsgrpc.Port {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			for _, tt := range []struct {
+				name                 string
+				inputPort            *portsgrpc.Port
+				expectedCode         codes.Code
+				expectedInvalidField []string
+				expectedPorts        []*portsgrpc.Port
+			}{
+				{
+					name:                 "nil port given",
+					expectedCode:         codes.InvalidArgument,
+					expectedInvalidField: []string{"port"},
+				}, {
+					name:                 "empty port given",
+					inputPort:            &portsgrpc.Port{},
+					expectedCode:         codes.InvalidArgument,
+					expectedInvalidField: []string{"id", "name"},
+				}, {
+					name:      "valid port given",
+					inputPort: newAjmanPort(),
+					expectedPorts: []*portsgrpc.Port{
+						withVersion(newAjmanPort(), 1),
+					},
+				}, {
+					name: "port with empty ID given",
+					inputPort: func() *portsgrpc.Port {
+						p := newAjmanPort()
+						p.Id = ""
+						return p
+					}(),
+					expectedCode:         codes.InvalidArgument,
+					expectedInvalidField: []string{"id"},
+				}, {
+					name: "port with empty name given",
+					inputPort: func() *portsgrpc.Port {
+						p := newAjmanPort()
+						p.Name = ""
+						return p
+					}(),
+					expectedCode:         codes.InvalidArgument,
+					expectedInvalidField: []string{"name"},
+				}, {
+					name: "port with location only given",
+					inputPort: func() *portsgrpc.Port {
+						p := newAjmanPort()
+						p.Coordinates = nil
+						return p
+					}(),
+					expectedPorts: []*portsgrpc.Port{
+						withVersion(newAjmanPort(), 1),
+					},
+				}, {
+					name: "port with legacy coordinates only given",
+					inputPort: func() *portsgrpc.Port {
+						p := newAjmanPort()
+						p.Location = nil
+						return p
+					}(),
+					expectedPorts: []*portsgrpc.Port{
+						withVersion(newAjmanPort(), 1),
+					},
+				}, {
+					name: "port with invalid legacy coordinates given",
+					inputPort: func() *portsgrpc.Port {
+						p := newAjmanPort()
+						p.Location = nil
+						p.Coordinates = []float64{55.5136433, 25.4052165, 0}
+						return p
+					}(),
+					expectedCode:         codes.InvalidArgument,
+					expectedInvalidField: []string{"coordinates"},
+				},
+			} {
+				t.Run(tt.name, func(t *testing.T) {
+					// Given
+					ctx, cancel := context.WithCancel(context.Background())
+					defer cancel()
+					client := startServer(ctx, t, repo.newConfig(t))
+
+					// When
+					_, err := client.StorePort(ctx, tt.inputPort)
+
+					// Then
+					assert.Equal(t, tt.expectedCode, status.Code(err))
+					assert.Equal(t, tt.expectedInvalidField, invalidFields(err))
+
+					ports, err := client.ListPorts(ctx)
+					assert.NoError(t, err)
+					assert.Equal(t, tt.expectedPorts, ports)
+				})
+			}
+		})
+	}
+}
+
+func TestPortsServer_StorePortsStream(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+
+			invalidPort := newAjmanPort()
+			invalidPort.Id = "AEAUH"
+			invalidPort.Name = ""
+
+			// When
+			stream, err := client.StorePorts(ctx)
+			require.NoError(t, err)
+			require.NoError(t, stream.Send(newAjmanPort()))
+			require.NoError(t, stream.Send(invalidPort))
+			require.NoError(t, stream.Send(nil))
+			summary, err := stream.CloseAndRecv()
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), summary.StoredCount)
+			assert.Equal(t, int64(2), summary.RejectedCount)
+			assert.Equal(t, int64(0), summary.FailedCount)
+			require.Len(t, summary.Errors, 2)
+			assert.Equal(t, int64(1), summary.Errors[0].Index)
+			assert.Equal(t, "AEAUH", summary.Errors[0].PortId)
+			assert.Equal(t, int32(codes.InvalidArgument), summary.Errors[0].Code)
+			assert.Equal(t, int64(2), summary.Errors[1].Index)
+
+			ports, err := client.ListPorts(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, []*portsgrpc.Port{withVersion(newAjmanPort(), 1)}, ports)
+		})
+	}
+}
+
+func TestPortsServer_StorePortOutcome(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+			renamed := newAjmanPort()
+			renamed.Name = "Ajman Port"
+
+			// When
+			var outcomes []portsgrpc.StorePortResponse_Outcome
+			for _, p := range []*portsgrpc.Port{newAjmanPort(), newAjmanPort(), renamed} {
+				outcome, err := client.StorePort(ctx, p)
+				require.NoError(t, err)
+				outcomes = append(outcomes, outcome)
+			}
+
+			// Then
+			assert.Equal(t, []portsgrpc.StorePortResponse_Outcome{
+				portsgrpc.StorePortResponse_CREATED,
+				portsgrpc.StorePortResponse_UNCHANGED,
+				portsgrpc.StorePortResponse_UPDATED,
+			}, outcomes)
+			stored, err := client.GetPort(ctx, "AEAJM")
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), stored.Version, "unchanged port should keep its version")
+			history, err := client.GetPortHistory(ctx, "AEAJM")
+			require.NoError(t, err)
+			assert.Len(t, history, 2, "unchanged port should have no history entry")
+
+			// When
+			stream, err := client.StorePorts(ctx)
+			require.NoError(t, err)
+			require.NoError(t, stream.Send(renamed))
+			require.NoError(t, stream.Send(newAjmanPort()))
+			require.NoError(t, stream.Send(newPort("AEAUH", nil)))
+			summary, err := stream.CloseAndRecv()
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), summary.StoredCount)
+			assert.Equal(t, int64(1), summary.CreatedCount)
+			assert.Equal(t, int64(1), summary.UpdatedCount)
+			assert.Equal(t, int64(1), summary.UnchangedCount)
+		})
+	}
+}
+
+func TestPortsServer_StorePortsStreamTruncatesErrors(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := startServer(ctx, t, portssvc.Config{Repository: portssvc.RepositoryInMemory})
+
+	// When
+	stream, err := client.StorePorts(ctx)
+	require.NoError(t, err)
+	for i := 0; i < portsgrpc.StorePortsMaxErrors+5; i++ {
+		require.NoError(t, stream.Send(nil))
+	}
+	summary, err := stream.CloseAndRecv()
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, int64(portsgrpc.StorePortsMaxErrors+5), summary.RejectedCount)
+	assert.Len(t, summary.Errors, portsgrpc.StorePortsMaxErrors)
+	assert.True(t, summary.ErrorsTruncated)
+}
+
+func TestPortsServer_GetPort(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			for _, tt := range []struct {
+				name         string
+				storedPorts  []*portsgrpc.Port
+				inputID      string
+				expectedCode codes.Code
+				expectedPort *portsgrpc.Port
+			}{
+				{
+					name:         "empty repository",
+					inputID:      "AEAJM",
+					expectedCode: codes.NotFound,
+				}, {
+					name:         "empty ID given",
+					storedPorts:  []*portsgrpc.Port{newAjmanPort()},
+					expectedCode: codes.NotFound,
+				}, {
+					name:         "unknown ID given",
+					storedPorts:  []*portsgrpc.Port{newAjmanPort()},
+					inputID:      "AEDXB",
+					expectedCode: codes.NotFound,
+				}, {
+					name:         "stored port requested",
+					storedPorts:  []*portsgrpc.Port{newAjmanPort()},
+					inputID:      "AEAJM",
+					expectedCode: codes.OK,
+					expectedPort: withVersion(newAjmanPort(), 1),
+				},
+			} {
+				t.Run(tt.name, func(t *testing.T) {
+					// Given
+					ctx, cancel := context.WithCancel(context.Background())
+					defer cancel()
+					client := startServer(ctx, t, repo.newConfig(t))
+
+					for _, p := range tt.storedPorts {
+						storePort(ctx, t, client, p)
+					}
+
+					// When
+					port, err := client.GetPort(ctx, tt.inputID)
+
+					// Then
+					assert.Equal(t, tt.expectedCode, status.Code(err))
+					assert.True(t, proto.Equal(tt.expectedPort, port), "expected: %v, actual: %v", tt.expectedPort, port)
+				})
+			}
+		})
+	}
+}
+
+func TestPortsServer_LookupPort(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+
+			dalian := newPort("CNDAL", nil)
+			dalian.Unlocs = []string{"CNDLC", "CNDAL"}
+			storePort(ctx, t, client, dalian)
+
+			// When
+			found, err := client.LookupPort(ctx, "cn dlc")
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, "CNDAL", found.GetId())
+
+			// When
+			dalianPort := newPort("CNDLC", nil)
+			dalianPort.Unlocs = []string{"CNDLC", "CNDAL"}
+			storePort(ctx, t, client, dalianPort)
+			foundByID, err := client.LookupPort(ctx, "CNDLC")
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, "CNDLC", foundByID.GetId())
+
+			// When
+			_, err = client.UpdatePort(ctx, &portsgrpc.Port{Id: "AEAJM", Unlocs: []string{"CNDLC"}}, "unlocs")
+
+			// Then
+			assert.Equal(t, codes.NotFound, status.Code(err))
+
+			// When
+			storePort(ctx, t, client, newAjmanPort())
+			_, err = client.UpdatePort(ctx, &portsgrpc.Port{Id: "AEAJM", Unlocs: []string{"CNDLC"}}, "unlocs")
+
+			// Then
+			assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+			// When
+			require.NoError(t, client.DeletePort(ctx, "CNDLC", false))
+			foundByUnloc, err := client.LookupPort(ctx, "CNDLC")
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, "CNDAL", foundByUnloc.GetId())
+
+			// When
+			require.NoError(t, client.DeletePort(ctx, "CNDAL", false))
+			_, lookupErr := client.LookupPort(ctx, "CNDAL")
+			_, updateErr := client.UpdatePort(ctx, &portsgrpc.Port{Id: "AEAJM", Unlocs: []string{"CNDLC"}}, "unlocs")
+			_, restoreErr := client.RestorePort(ctx, "CNDAL")
+
+			// Then
+			assert.Equal(t, codes.NotFound, status.Code(lookupErr))
+			assert.NoError(t, updateErr)
+			assert.Equal(t, codes.AlreadyExists, status.Code(restoreErr))
+
+			found, err = client.LookupPort(ctx, "CNDLC")
+			require.NoError(t, err)
+			assert.Equal(t, "AEAJM", found.GetId())
+		})
+	}
+}
+
+func TestPortsServer_ListPortsPage(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+
+			for _, id := range []string{"AEDXB", "AEAUH", "AEAJM"} {
 				p := newAjmanPort()
-				p.Name = ""
-				return p
-			}(),
-			expectedError: true,
-		},
-	} {
-		t.Run(tt.name, func(t *testing.T) {
+				p.Id = id
+				p.Unlocs = []string{id}
+				storePort(ctx, t, client, p)
+			}
+
+			// When
+			firstPage, pageToken, err := client.ListPortsPage(ctx, 2, "")
+
+			// Then
+			require.NoError(t, err)
+			require.Len(t, firstPage, 2)
+			assert.Equal(t, "AEAJM", firstPage[0].Id)
+			assert.Equal(t, "AEAUH", firstPage[1].Id)
+			assert.NotEmpty(t, pageToken)
+
+			// When
+			secondPage, pageToken, err := client.ListPortsPage(ctx, 2, pageToken)
+
+			// Then
+			require.NoError(t, err)
+			require.Len(t, secondPage, 1)
+			assert.Equal(t, "AEDXB", secondPage[0].Id)
+			assert.Empty(t, pageToken)
+
+			// When
+			_, _, err = client.ListPortsPage(ctx, 2, "!invalid!")
+
+			// Then
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestPortsServer_SearchPorts(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
 			// Given
 			ctx, cancel := context.WithCancel(context.Background())
-			server := portssvc.NewServer(portssvc.Config{
-				GRPCServerAddress: ":0",
-			})
-			go func() {
-				err := server.Serve(ctx)
-				assert.NoError(t, err)
-			}()
 			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
 
-			client, err := portsclient.NewGRPC(server.Address().String())
+			for _, p := range []struct {
+				id, name, country string
+				alias             []string
+			}{
+				{id: "AEAJM", name: "Ajman", country: "United Arab Emirates", alias: []string{"Ujman"}},
+				{id: "AEAUH", name: "Abu Dhabi", country: "United Arab Emirates"},
+				{id: "AEDXB", name: "Dubai", country: "United Arab Emirates", alias: []string{"Port Rashid"}},
+				{id: "OMMCT", name: "Muscat", country: "Oman"},
+				{id: "AEFJR", name: "Fujairah", country: "United Arab Emirates"},
+			} {
+				port := newPort(p.id, nil)
+				port.Name = p.name
+				port.Country = p.country
+				port.Alias = p.alias
+				storePort(ctx, t, client, port)
+			}
+			require.NoError(t, client.DeletePort(ctx, "AEFJR", false))
+
+			// When
+			firstPage, pageToken, err := client.SearchPortsPage(ctx, &portsgrpc.SearchPortsRequest{
+				Country:  "United Arab Emirates",
+				PageSize: 2,
+			})
+
+			// Then
 			require.NoError(t, err)
+			assert.Equal(t, []string{"AEAJM", "AEAUH"}, portIDs(firstPage))
+			assert.NotEmpty(t, pageToken)
 
 			// When
-			err = client.StorePort(ctx, tt.inputPort)
+			secondPage, pageToken, err := client.SearchPortsPage(ctx, &portsgrpc.SearchPortsRequest{
+				Country:   "United Arab Emirates",
+				PageSize:  2,
+				PageToken: pageToken,
+			})
 
 			// Then
-			if tt.expectedError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"AEDXB"}, portIDs(secondPage))
+			assert.Empty(t, pageToken)
+
+			// When
+			byName, _, err := client.SearchPortsPage(ctx, &portsgrpc.SearchPortsRequest{NamePrefix: "a"})
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, []string{"AEAJM", "AEAUH"}, portIDs(byName))
+
+			// When
+			byAlias, _, err := client.SearchPortsPage(ctx, &portsgrpc.SearchPortsRequest{
+				NamePrefix: "PORT R",
+				Timezone:   "Asia/Dubai",
+			})
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, []string{"AEDXB"}, portIDs(byAlias))
+
+			// When
+			_, _, err = client.SearchPortsPage(ctx, &portsgrpc.SearchPortsRequest{PageSize: -1})
+
+			// Then
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Equal(t, []string{"page_size"}, invalidFields(err))
+		})
+	}
+}
+
+func TestPortsServer_SuggestPorts(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+
+			for _, p := range []struct {
+				id, name, city, province string
+			}{
+				{id: "AEAUH", name: "Abu Dhabi", city: "Abu Dhabi", province: "Abu Z¸aby [Abu Dhabi]"},
+				{id: "AEJEA", name: "Jebel Ali", city: "Jebel Ali", province: "Dubai"},
+				{id: "AEJED", name: "Jebel Dhanna", city: "Jebel Dhanna", province: "Abu Dhabi"},
+				{id: "AEDXB", name: "Dubai", city: "Dubai", province: "Dubayy [Dubai]"},
+			} {
+				port := newPort(p.id, nil)
+				port.Name = p.name
+				port.City = p.city
+				port.Province = p.province
+				storePort(ctx, t, client, port)
+			}
+
+			for _, tt := range []struct {
+				query         string
+				expectedIDs   []string
+				expectedScore float64
+			}{
+				{query: "Jebel Aly", expectedIDs: []string{"AEJEA"}, expectedScore: 0.875},
+				{query: "Abu Zaby", expectedIDs: []string{"AEAUH"}, expectedScore: 0.8},
+				{query: "abu dhabi", expectedIDs: []string{"AEAUH", "AEJED"}, expectedScore: 1},
+				{query: "dub", expectedIDs: []string{"AEDXB", "AEJEA"}, expectedScore: 0.8},
+			} {
+				// When
+				suggested, err := client.SuggestPorts(ctx, tt.query, 0)
+
+				// Then
+				require.NoError(t, err, tt.query)
+				ids := make([]string, 0, len(suggested))
+				for _, sp := range suggested {
+					ids = append(ids, sp.GetPort().GetId())
+				}
+				assert.Equal(t, tt.expectedIDs, ids, tt.query)
+				require.NotEmpty(t, suggested, tt.query)
+				assert.InDelta(t, tt.expectedScore, suggested[0].Score, 1e-9, tt.query)
+			}
+
+			// When
+			_, err := client.SuggestPorts(ctx, " - ", -1)
+
+			// Then
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Equal(t, []string{"query", "limit"}, invalidFields(err))
+		})
+	}
+}
+
+func portIDs(ps []*portsgrpc.Port) []string {
+	ids := make([]string, 0, len(ps))
+	for _, p := range ps {
+		ids = append(ids, p.GetId())
+	}
+	return ids
+}
+
+func TestPortsServer_StreamPorts(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+
+			ids := []string{"AEAJM", "AEAUH", "AEDXB"}
+			for _, id := range ids {
+				p := newAjmanPort()
+				p.Id = id
+				p.Unlocs = []string{id}
+				storePort(ctx, t, client, p)
+			}
+
+			// When
+			it, err := client.StreamPorts(ctx)
+			require.NoError(t, err)
+
+			var streamedIDs []string
+			for it.Next() {
+				streamedIDs = append(streamedIDs, it.Port().Id)
+			}
+
+			// Then
+			assert.NoError(t, it.Err())
+			assert.Equal(t, ids, streamedIDs)
+		})
+	}
+}
+
+func TestPortsServer_UpdatePort(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			for _, tt := range []struct {
+				name         string
+				inputPort    *portsgrpc.Port
+				inputPaths   []string
+				expectedCode codes.Code
+				expectedPort *portsgrpc.Port
+			}{
+				{
+					name:         "unknown field given",
+					inputPort:    &portsgrpc.Port{Id: "AEAJM"},
+					inputPaths:   []string{"time_zone"},
+					expectedCode: codes.InvalidArgument,
+					expectedPort: withVersion(newAjmanPort(), 1),
+				}, {
+					name:         "unknown port given",
+					inputPort:    &portsgrpc.Port{Id: "AEDXB", Timezone: "Asia/Muscat"},
+					inputPaths:   []string{"timezone"},
+					expectedCode: codes.NotFound,
+					expectedPort: withVersion(newAjmanPort(), 1),
+				}, {
+					name:         "update makes port invalid",
+					inputPort:    &portsgrpc.Port{Id: "AEAJM"},
+					inputPaths:   []string{"name"},
+					expectedCode: codes.InvalidArgument,
+					expectedPort: withVersion(newAjmanPort(), 1),
+				}, {
+					name:         "timezone update given",
+					inputPort:    &portsgrpc.Port{Id: "AEAJM", Timezone: "Asia/Muscat"},
+					inputPaths:   []string{"timezone"},
+					expectedCode: codes.OK,
+					expectedPort: func() *portsgrpc.Port {
+						p := withVersion(newAjmanPort(), 2)
+						p.Timezone = "Asia/Muscat"
+						return p
+					}(),
+				}, {
+					name:         "stale version given",
+					inputPort:    &portsgrpc.Port{Id: "AEAJM", Timezone: "Asia/Muscat", Version: 2},
+					inputPaths:   []string{"timezone"},
+					expectedCode: codes.Aborted,
+					expectedPort: withVersion(newAjmanPort(), 1),
+				}, {
+					name:         "current version given",
+					inputPort:    &portsgrpc.Port{Id: "AEAJM", Timezone: "Asia/Muscat", Version: 1},
+					inputPaths:   []string{"timezone"},
+					expectedCode: codes.OK,
+					expectedPort: func() *portsgrpc.Port {
+						p := withVersion(newAjmanPort(), 2)
+						p.Timezone = "Asia/Muscat"
+						return p
+					}(),
+				},
+			} {
+				t.Run(tt.name, func(t *testing.T) {
+					// Given
+					ctx, cancel := context.WithCancel(context.Background())
+					defer cancel()
+					client := startServer(ctx, t, repo.newConfig(t))
+					storePort(ctx, t, client, newAjmanPort())
+
+					// When
+					_, err := client.UpdatePort(ctx, tt.inputPort, tt.inputPaths...)
+
+					// Then
+					assert.Equal(t, tt.expectedCode, status.Code(err))
+
+					port, err := client.GetPort(ctx, "AEAJM")
+					require.NoError(t, err)
+					assert.True(t, proto.Equal(tt.expectedPort, port), "expected: %v, actual: %v", tt.expectedPort, port)
+				})
+			}
+		})
+	}
+}
+
+func TestPortsServer_FindNearbyPorts(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+
+			for _, p := range []*portsgrpc.Port{
+				newAjmanPort(),
+				newPort("AEAUH", &portsgrpc.LatLng{Latitude: 24.47, Longitude: 54.37}),
+				newPort("AEDXB", &portsgrpc.LatLng{Latitude: 25.25, Longitude: 55.27}),
+				newPort("AEFJR", &portsgrpc.LatLng{Latitude: 25.12, Longitude: 56.33}),
+				newPort("AEQIW", nil),
+				newPort("NZAKL", &portsgrpc.LatLng{Latitude: -36.84, Longitude: 174.77}),
+			} {
+				storePort(ctx, t, client, p)
 			}
+			require.NoError(t, client.DeletePort(ctx, "AEFJR", false))
+			dubai := &portsgrpc.LatLng{Latitude: 25.2048, Longitude: 55.2708}
+
+			// When
+			nearest, err := client.FindNearbyPorts(ctx, dubai, 0, 2)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, []string{"AEDXB", "AEAJM"}, nearbyPortIDs(nearest))
+			assert.InDelta(t, 5, nearest[0].DistanceKm, 1)
+			assert.InDelta(t, 33, nearest[1].DistanceKm, 1)
+			assert.Equal(t, "Ajman", nearest[1].Port.Name)
+
+			// When
+			withinRadius, err := client.FindNearbyPorts(ctx, dubai, 100, 0)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, []string{"AEDXB", "AEAJM"}, nearbyPortIDs(withinRadius))
+
+			// When
+			all, err := client.FindNearbyPorts(ctx, dubai, 0, 0)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, []string{"AEDXB", "AEAJM", "AEAUH", "NZAKL"}, nearbyPortIDs(all))
+
+			// When
+			_, err = client.FindNearbyPorts(ctx, nil, -1, 0)
+
+			// Then
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Equal(t, []string{"location", "radius_km"}, invalidFields(err))
+		})
+	}
+}
+
+func nearbyPortIDs(nearby []*portsgrpc.NearbyPort) []string {
+	ids := make([]string, 0, len(nearby))
+	for _, n := range nearby {
+		ids = append(ids, n.GetPort().GetId())
+	}
+	return ids
+}
+
+func TestPortsServer_StorePortVersion(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+
+			_, err := client.StorePort(ctx, withVersion(newAjmanPort(), 1))
+			assert.Equal(t, codes.Aborted, status.Code(err), "expected version of not stored port given")
+
+			storePort(ctx, t, client, newAjmanPort())
+			stored, err := client.GetPort(ctx, "AEAJM")
+			require.NoError(t, err)
+			require.Equal(t, int64(1), stored.Version)
+
+			// When
+			stored.Name = "Ajman Port"
+			_, err = client.StorePort(ctx, stored)
+
+			// Then
+			require.NoError(t, err)
+
+			// When
+			stored.Name = "Lost Update"
+			_, err = client.StorePort(ctx, stored)
+
+			// Then
+			assert.Equal(t, codes.Aborted, status.Code(err))
+
+			port, err := client.GetPort(ctx, "AEAJM")
+			require.NoError(t, err)
+			assert.Equal(t, "Ajman Port", port.Name)
+			assert.Equal(t, int64(2), port.Version)
+		})
+	}
+}
+
+func TestPortsServer_DeletePort(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+			storePort(ctx, t, client, newAjmanPort())
+
+			// When
+			err := client.DeletePort(ctx, "AEAJM", false)
 
+			// Then
+			require.NoError(t, err)
+			_, err = client.GetPort(ctx, "AEAJM")
+			assert.Equal(t, codes.NotFound, status.Code(err))
 			ports, err := client.ListPorts(ctx)
-			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedPorts, ports)
+			require.NoError(t, err)
+			assert.Empty(t, ports)
+
+			err = client.DeletePort(ctx, "AEAJM", false)
+			assert.Equal(t, codes.NotFound, status.Code(err), "soft-deleted port cannot be deleted again")
+
+			// When
+			restored, err := client.RestorePort(ctx, "AEAJM")
+
+			// Then
+			require.NoError(t, err)
+			// Both the deletion and the restoration increment the version
+			assert.True(t, proto.Equal(withVersion(newAjmanPort(), 3), restored), "actual: %v", restored)
+			ports, err = client.ListPorts(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []*portsgrpc.Port{withVersion(newAjmanPort(), 3)}, ports)
+
+			_, err = client.RestorePort(ctx, "AEAJM")
+			assert.Equal(t, codes.NotFound, status.Code(err), "live port cannot be restored")
+			_, err = client.StorePort(ctx, withVersion(newAjmanPort(), 1))
+			assert.Equal(t, codes.Aborted, status.Code(err), "version before the deletion is stale")
+
+			// When
+			err = client.DeletePort(ctx, "AEAJM", true)
+
+			// Then
+			require.NoError(t, err)
+			_, err = client.RestorePort(ctx, "AEAJM")
+			assert.Equal(t, codes.NotFound, status.Code(err), "purged port cannot be restored")
+			err = client.DeletePort(ctx, "AEAJM", true)
+			assert.Equal(t, codes.NotFound, status.Code(err))
 		})
 	}
 }
 
+func TestPortsServer_WatchPorts(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+			storePort(ctx, t, client, newPort("AEAUH", nil))
+
+			// When
+			it, err := client.WatchPorts(ctx, 0)
+			require.NoError(t, err)
+
+			storePort(ctx, t, client, newAjmanPort())
+			updated, err := client.UpdatePort(ctx, &portsgrpc.Port{Id: "AEAJM", Timezone: "Asia/Muscat"}, "timezone")
+			require.NoError(t, err)
+			require.NoError(t, client.DeletePort(ctx, "AEAJM", true))
+
+			// Then
+			events := receivePortEvents(t, it, 3)
+			assert.Equal(t, []portsgrpc.PortEvent_Type{
+				portsgrpc.PortEvent_CREATED, portsgrpc.PortEvent_UPDATED, portsgrpc.PortEvent_DELETED,
+			}, portEventTypes(events))
+			assert.True(t, proto.Equal(withVersion(newAjmanPort(), 1), events[0].Port), "actual: %v", events[0].Port)
+			assert.True(t, proto.Equal(updated, events[1].Port), "actual: %v", events[1].Port)
+			assert.True(t, proto.Equal(updated, events[2].Port), "actual: %v", events[2].Port)
+			assert.Less(t, events[0].Revision, events[1].Revision)
+			assert.Less(t, events[1].Revision, events[2].Revision)
+
+			// When
+			it, err = client.WatchPorts(ctx, events[0].Revision)
+			require.NoError(t, err)
+
+			// Then
+			resumed := receivePortEvents(t, it, 2)
+			for i, e := range resumed {
+				assert.True(t, proto.Equal(events[i+1], e), "actual: %v", e)
+			}
+
+			// When
+			it, err = client.WatchPorts(ctx, events[2].Revision+1)
+			require.NoError(t, err)
+
+			// Then
+			assert.False(t, it.Next())
+			assert.Equal(t, codes.InvalidArgument, status.Code(it.Err()), "future revision")
+
+			// When
+			it, err = client.WatchPorts(ctx, 1)
+			require.NoError(t, err)
+
+			// Then
+			assert.False(t, it.Next())
+			assert.Equal(t, codes.OutOfRange, status.Code(it.Err()), "compacted revision")
+		})
+	}
+}
+
+func TestPortsServer_StopWithWatcher(t *testing.T) {
+	// Given
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	server, err := portssvc.NewServer(ctx, portssvc.Config{GRPCServerAddress: ":0"})
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(ctx)
+	}()
+
+	client, err := portsclient.NewGRPC(server.Address().String(), portsclient.Options{})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, client.Close())
+	}()
+	it, err := client.WatchPorts(context.Background(), 0)
+	require.NoError(t, err)
+
+	// When
+	stop()
+
+	// Then
+	select {
+	case err = <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop with open watch stream")
+	}
+	assert.False(t, it.Next())
+	assert.Equal(t, codes.Unavailable, status.Code(it.Err()), "error: %v", it.Err())
+}
+
+// receivePortEvents receives given number of events from the iterator.
+func receivePortEvents(t *testing.T, it *portsclient.PortEventIterator, count int) []*portsgrpc.PortEvent {
+	t.Helper()
+	var events []*portsgrpc.PortEvent
+	for len(events) < count && it.Next() {
+		events = append(events, it.Event())
+	}
+	require.NoError(t, it.Err())
+	require.Len(t, events, count)
+	return events
+}
+
+func portEventTypes(events []*portsgrpc.PortEvent) []portsgrpc.PortEvent_Type {
+	types := make([]portsgrpc.PortEvent_Type, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestPortsServer_GetPortHistory(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := startServer(ctx, t, repo.newConfig(t))
+
+			stream, err := client.StorePorts(portsclient.WithCaller(ctx, "ingest-test"))
+			require.NoError(t, err)
+			require.NoError(t, stream.Send(newAjmanPort()))
+			_, err = stream.CloseAndRecv()
+			require.NoError(t, err)
+
+			operatorCtx := portsclient.WithCaller(ctx, "operator")
+			_, err = client.UpdatePort(operatorCtx, &portsgrpc.Port{Id: "AEAJM", Timezone: "Asia/Muscat"}, "timezone")
+			require.NoError(t, err)
+			require.NoError(t, client.DeletePort(operatorCtx, "AEAJM", true))
+
+			// When
+			history, err := client.GetPortHistory(ctx, "AEAJM")
+
+			// Then
+			require.NoError(t, err)
+			require.Len(t, history, 3)
+			assert.Equal(t, []portsgrpc.PortEvent_Type{
+				portsgrpc.PortEvent_CREATED,
+				portsgrpc.PortEvent_UPDATED,
+				portsgrpc.PortEvent_DELETED,
+			}, historyEntryTypes(history))
+			assert.Equal(t, []string{"ingest-test", "operator", "operator"}, historyEntryCallers(history))
+
+			assert.Nil(t, history[0].Previous)
+			assert.True(t, proto.Equal(withVersion(newAjmanPort(), 1), history[0].Port), "actual: %v", history[0].Port)
+			assert.True(t, proto.Equal(history[0].Port, history[1].Previous), "actual: %v", history[1].Previous)
+			assert.Equal(t, "Asia/Muscat", history[1].Port.Timezone)
+			assert.True(t, proto.Equal(history[1].Port, history[2].Previous), "actual: %v", history[2].Previous)
+			assert.Nil(t, history[2].Port)
+			assert.WithinDuration(t, time.Now(), history[2].Time.AsTime(), time.Minute)
+
+			// When
+			_, err = client.GetPortHistory(ctx, "AEAUH")
+
+			// Then
+			assert.Equal(t, codes.NotFound, status.Code(err))
+		})
+	}
+}
+
+func historyEntryTypes(entries []*portsgrpc.PortHistoryEntry) []portsgrpc.PortEvent_Type {
+	result := make([]portsgrpc.PortEvent_Type, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.Type)
+	}
+	return result
+}
+
+func historyEntryCallers(entries []*portsgrpc.PortHistoryEntry) []string {
+	result := make([]string, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.Caller)
+	}
+	return result
+}
+
+func TestPortsServer_PublishPortChanges(t *testing.T) {
+	for _, repo := range testRepositories() {
+		repo := repo
+		t.Run(repo.name, func(t *testing.T) {
+			// Given
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			cfg := repo.newConfig(t)
+			cfg.Publisher = portssvc.PublisherFile
+			cfg.PublisherFile = filepath.Join(t.TempDir(), "port-changes.jsonl")
+			cfg.OutboxPollInterval = 10 * time.Millisecond
+			client := startServer(ctx, t, cfg)
+
+			// When
+			storePort(ctx, t, client, newAjmanPort())
+			renamed := newAjmanPort()
+			renamed.Name = "Ajman Port"
+			storePort(ctx, t, client, renamed)
+			require.NoError(t, client.DeletePort(ctx, "AEAJM", true))
+
+			// Then
+			var lines []string
+			assert.Eventually(t, func() bool {
+				content, err := os.ReadFile(cfg.PublisherFile)
+				require.NoError(t, err)
+				lines = strings.Split(strings.TrimSpace(string(content)), "\n")
+				return len(lines) == 3
+			}, 5*time.Second, 10*time.Millisecond)
+
+			var types []string
+			for _, line := range lines {
+				var event struct {
+					Type string `json:"type"`
+					Port struct {
+						ID      string `json:"id"`
+						Version int64  `json:"version"`
+					} `json:"port"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(line), &event))
+				assert.Equal(t, "AEAJM", event.Port.ID)
+				types = append(types, fmt.Sprintf("%v:%v", event.Type, event.Port.Version))
+			}
+			assert.Equal(t, []string{"created:1", "updated:2", "deleted:2"}, types)
+		})
+	}
+}
+
+func TestPortsServer_BoltRepositoryRestart(t *testing.T) {
+	// Given
+	cfg := portssvc.Config{
+		GRPCServerAddress: ":0",
+		Repository:        portssvc.RepositoryBolt,
+		DataDir:           t.TempDir(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server, err := portssvc.NewServer(ctx, cfg)
+	require.NoError(t, err)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		assert.NoError(t, server.Serve(ctx))
+	}()
+
+	client, err := portsclient.NewGRPC(server.Address().String(), portsclient.Options{})
+	require.NoError(t, err)
+	storePort(ctx, t, client, newAjmanPort())
+	require.NoError(t, client.Close())
+
+	// When
+	cancel()
+	<-stopped
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	client = startServer(ctx, t, cfg)
+
+	// Then
+	ports, err := client.ListPorts(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*portsgrpc.Port{withVersion(newAjmanPort(), 1)}, ports)
+	history, err := client.GetPortHistory(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, []portsgrpc.PortEvent_Type{portsgrpc.PortEvent_CREATED}, historyEntryTypes(history))
+}
+
+type testRepository struct {
+	name string
+	// newConfig returns a server config with an empty repository.
+	newConfig func(t *testing.T) portssvc.Config
+}
+
+// testRepositories returns repositories that the component tests are run against.
+// PostgreSQL repository is included only if POSTGRES_TEST_URL env var is set.
+func testRepositories() []testRepository {
+	repos := []testRepository{
+		{
+			name: portssvc.RepositoryInMemory,
+			newConfig: func(t *testing.T) portssvc.Config {
+				return portssvc.Config{Repository: portssvc.RepositoryInMemory}
+			},
+		}, {
+			name: portssvc.RepositoryInMemory + "-with-wal",
+			newConfig: func(t *testing.T) portssvc.Config {
+				return portssvc.Config{
+					Repository: portssvc.RepositoryInMemory,
+					WALDir:     t.TempDir(),
+				}
+			},
+		}, {
+			name: portssvc.RepositoryBolt,
+			newConfig: func(t *testing.T) portssvc.Config {
+				return portssvc.Config{
+					Repository: portssvc.RepositoryBolt,
+					DataDir:    t.TempDir(),
+				}
+			},
+		},
+	}
+
+	if url := os.Getenv(postgresTestURLEnv); url != "" {
+		repos = append(repos, testRepository{
+			name: portssvc.RepositoryPostgres,
+			newConfig: func(t *testing.T) portssvc.Config {
+				cleanUpPostgres(t, url)
+				return portssvc.Config{
+					Repository:  portssvc.RepositoryPostgres,
+					PostgresURL: url,
+				}
+			},
+		})
+	}
+	return repos
+}
+
+// postgresTestURLEnv is a name of env var with PostgreSQL connection URL used by tests.
+const postgresTestURLEnv = "POSTGRES_TEST_URL"
+
+func cleanUpPostgres(t *testing.T, url string) {
+	db, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS port_history, port_changes, port_names, port_unlocs, ports, schema_migrations")
+	require.NoError(t, err)
+}
+
+// startServer starts Ports server with given config on a random port and returns a client connected to it.
+// The server is stopped on context cancel, and the test cleanup waits until it is stopped.
+func startServer(ctx context.Context, t *testing.T, cfg portssvc.Config) portsclient.GRPC {
+	cfg.GRPCServerAddress = ":0"
+	server, err := portssvc.NewServer(ctx, cfg)
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		assert.NoError(t, server.Serve(ctx))
+	}()
+	t.Cleanup(func() {
+		<-stopped
+	})
+
+	client, err := portsclient.NewGRPC(server.Address().String(), portsclient.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, client.Close())
+	})
+	return client
+}
+
+// storePort stores the port and fails the test if it is not stored.
+func storePort(ctx context.Context, t *testing.T, client portsclient.GRPC, p *portsgrpc.Port) {
+	t.Helper()
+	_, err := client.StorePort(ctx, p)
+	require.NoError(t, err)
+}
+
+// invalidFields returns fields listed in google.rpc.BadRequest details of the gRPC status error.
+func invalidFields(err error) []string {
+	var fields []string
+	for _, d := range status.Convert(err).Details() {
+		if badRequest, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	return fields
+}
+
+// withVersion sets the version of given port, so that it can be compared with the stored one.
+func withVersion(p *portsgrpc.Port, version int64) *portsgrpc.Port {
+	p.Version = version
+	return p
+}
+
+// newPort returns the port with given ID, unlocs and location, and other fields of Ajman port.
+func newPort(id string, location *portsgrpc.LatLng) *portsgrpc.Port {
+	p := newAjmanPort()
+	p.Id = id
+	p.Unlocs = []string{id}
+	p.Location = location
+	p.Coordinates = nil
+	return p
+}
+
 func newAjmanPort() *portsgrpc.Port {
 	return &portsgrpc.Port{
 		Id:          "AEAJM",
@@ -90,6 +1204,7 @@ func newAjmanPort() *portsgrpc.Port {
 		Alias:       []string{"foo-alias", "bar-alias"},
 		Regions:     []string{"foo-region", "bar-region"},
 		Coordinates: []float64{55.5136433, 25.4052165},
+		Location:    &portsgrpc.LatLng{Latitude: 25.4052165, Longitude: 55.5136433},
 		Province:    "Ajman",
 		Timezone:    "Asia/Dubai",
 		Unlocs:      []string{"AEAJM"},
@@ -0,0 +1,6 @@
+package portsgrpc
+
+// StorePortsMaxErrors is a maximum number of errors listed in the store ports summary. It keeps the summary
+// within the gRPC message size limit when most of the streamed ports fail. Clients that need the errors of all ports
+// send at most that many ports over a stream.
+const StorePortsMaxErrors = 1000
@@ -0,0 +1,6 @@
+package portsgrpc
+
+// CallerMetadataKey is a key of the request metadata with the identity of the caller, which Ports service records
+// in the history of the ports changed by the request. The identity is self-declared by the client and it is not
+// authenticated, so it only tells which client made the change if the clients are trusted.
+const CallerMetadataKey = "x-caller"
@@ -23,8 +23,20 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PortServiceClient interface {
-	StorePort(ctx context.Context, in *StorePortRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
-	ListPorts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListPortsResponse, error)
+	StorePort(ctx context.Context, in *StorePortRequest, opts ...grpc.CallOption) (*StorePortResponse, error)
+	StorePorts(ctx context.Context, opts ...grpc.CallOption) (PortService_StorePortsClient, error)
+	ListPorts(ctx context.Context, in *ListPortsRequest, opts ...grpc.CallOption) (*ListPortsResponse, error)
+	SearchPorts(ctx context.Context, in *SearchPortsRequest, opts ...grpc.CallOption) (*SearchPortsResponse, error)
+	SuggestPorts(ctx context.Context, in *SuggestPortsRequest, opts ...grpc.CallOption) (*SuggestPortsResponse, error)
+	GetPort(ctx context.Context, in *GetPortRequest, opts ...grpc.CallOption) (*Port, error)
+	LookupPort(ctx context.Context, in *LookupPortRequest, opts ...grpc.CallOption) (*Port, error)
+	StreamPorts(ctx context.Context, in *StreamPortsRequest, opts ...grpc.CallOption) (PortService_StreamPortsClient, error)
+	DeletePort(ctx context.Context, in *DeletePortRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	RestorePort(ctx context.Context, in *RestorePortRequest, opts ...grpc.CallOption) (*Port, error)
+	UpdatePort(ctx context.Context, in *UpdatePortRequest, opts ...grpc.CallOption) (*Port, error)
+	FindNearbyPorts(ctx context.Context, in *FindNearbyPortsRequest, opts ...grpc.CallOption) (*FindNearbyPortsResponse, error)
+	WatchPorts(ctx context.Context, in *WatchPortsRequest, opts ...grpc.CallOption) (PortService_WatchPortsClient, error)
+	GetPortHistory(ctx context.Context, in *GetPortHistoryRequest, opts ...grpc.CallOption) (*GetPortHistoryResponse, error)
 }
 
 type portServiceClient struct {
@@ -35,8 +47,8 @@ func NewPortServiceClient(cc grpc.ClientConnInterface) PortServiceClient {
 	return &portServiceClient{cc}
 }
 
-func (c *portServiceClient) StorePort(ctx context.Context, in *StorePortRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	out := new(emptypb.Empty)
+func (c *portServiceClient) StorePort(ctx context.Context, in *StorePortRequest, opts ...grpc.CallOption) (*StorePortResponse, error) {
+	out := new(StorePortResponse)
 	err := c.cc.Invoke(ctx, "/ports.PortService/StorePort", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -44,7 +56,41 @@ func (c *portServiceClient) StorePort(ctx context.Context, in *StorePortRequest,
 	return out, nil
 }
 
-func (c *portServiceClient) ListPorts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListPortsResponse, error) {
+func (c *portServiceClient) StorePorts(ctx context.Context, opts ...grpc.CallOption) (PortService_StorePortsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PortService_ServiceDesc.Streams[0], "/ports.PortService/StorePorts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &portServiceStorePortsClient{stream}
+	return x, nil
+}
+
+type PortService_StorePortsClient interface {
+	Send(*StorePortRequest) error
+	CloseAndRecv() (*StorePortsResponse, error)
+	grpc.ClientStream
+}
+
+type portServiceStorePortsClient struct {
+	grpc.ClientStream
+}
+
+func (x *portServiceStorePortsClient) Send(m *StorePortRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *portServiceStorePortsClient) CloseAndRecv() (*StorePortsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(StorePortsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *portServiceClient) ListPorts(ctx context.Context, in *ListPortsRequest, opts ...grpc.CallOption) (*ListPortsResponse, error) {
 	out := new(ListPortsResponse)
 	err := c.cc.Invoke(ctx, "/ports.PortService/ListPorts", in, out, opts...)
 	if err != nil {
@@ -53,12 +99,169 @@ func (c *portServiceClient) ListPorts(ctx context.Context, in *emptypb.Empty, op
 	return out, nil
 }
 
+func (c *portServiceClient) SearchPorts(ctx context.Context, in *SearchPortsRequest, opts ...grpc.CallOption) (*SearchPortsResponse, error) {
+	out := new(SearchPortsResponse)
+	err := c.cc.Invoke(ctx, "/ports.PortService/SearchPorts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) SuggestPorts(ctx context.Context, in *SuggestPortsRequest, opts ...grpc.CallOption) (*SuggestPortsResponse, error) {
+	out := new(SuggestPortsResponse)
+	err := c.cc.Invoke(ctx, "/ports.PortService/SuggestPorts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) GetPort(ctx context.Context, in *GetPortRequest, opts ...grpc.CallOption) (*Port, error) {
+	out := new(Port)
+	err := c.cc.Invoke(ctx, "/ports.PortService/GetPort", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) LookupPort(ctx context.Context, in *LookupPortRequest, opts ...grpc.CallOption) (*Port, error) {
+	out := new(Port)
+	err := c.cc.Invoke(ctx, "/ports.PortService/LookupPort", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) StreamPorts(ctx context.Context, in *StreamPortsRequest, opts ...grpc.CallOption) (PortService_StreamPortsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PortService_ServiceDesc.Streams[1], "/ports.PortService/StreamPorts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &portServiceStreamPortsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PortService_StreamPortsClient interface {
+	Recv() (*Port, error)
+	grpc.ClientStream
+}
+
+type portServiceStreamPortsClient struct {
+	grpc.ClientStream
+}
+
+func (x *portServiceStreamPortsClient) Recv() (*Port, error) {
+	m := new(Port)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *portServiceClient) DeletePort(ctx context.Context, in *DeletePortRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ports.PortService/DeletePort", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) RestorePort(ctx context.Context, in *RestorePortRequest, opts ...grpc.CallOption) (*Port, error) {
+	out := new(Port)
+	err := c.cc.Invoke(ctx, "/ports.PortService/RestorePort", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) UpdatePort(ctx context.Context, in *UpdatePortRequest, opts ...grpc.CallOption) (*Port, error) {
+	out := new(Port)
+	err := c.cc.Invoke(ctx, "/ports.PortService/UpdatePort", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) FindNearbyPorts(ctx context.Context, in *FindNearbyPortsRequest, opts ...grpc.CallOption) (*FindNearbyPortsResponse, error) {
+	out := new(FindNearbyPortsResponse)
+	err := c.cc.Invoke(ctx, "/ports.PortService/FindNearbyPorts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) WatchPorts(ctx context.Context, in *WatchPortsRequest, opts ...grpc.CallOption) (PortService_WatchPortsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PortService_ServiceDesc.Streams[2], "/ports.PortService/WatchPorts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &portServiceWatchPortsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PortService_WatchPortsClient interface {
+	Recv() (*PortEvent, error)
+	grpc.ClientStream
+}
+
+type portServiceWatchPortsClient struct {
+	grpc.ClientStream
+}
+
+func (x *portServiceWatchPortsClient) Recv() (*PortEvent, error) {
+	m := new(PortEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *portServiceClient) GetPortHistory(ctx context.Context, in *GetPortHistoryRequest, opts ...grpc.CallOption) (*GetPortHistoryResponse, error) {
+	out := new(GetPortHistoryResponse)
+	err := c.cc.Invoke(ctx, "/ports.PortService/GetPortHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PortServiceServer is the server API for PortService service.
 // All implementations must embed UnimplementedPortServiceServer
 // for forward compatibility
 type PortServiceServer interface {
-	StorePort(context.Context, *StorePortRequest) (*emptypb.Empty, error)
-	ListPorts(context.Context, *emptypb.Empty) (*ListPortsResponse, error)
+	StorePort(context.Context, *StorePortRequest) (*StorePortResponse, error)
+	StorePorts(PortService_StorePortsServer) error
+	ListPorts(context.Context, *ListPortsRequest) (*ListPortsResponse, error)
+	SearchPorts(context.Context, *SearchPortsRequest) (*SearchPortsResponse, error)
+	SuggestPorts(context.Context, *SuggestPortsRequest) (*SuggestPortsResponse, error)
+	GetPort(context.Context, *GetPortRequest) (*Port, error)
+	LookupPort(context.Context, *LookupPortRequest) (*Port, error)
+	StreamPorts(*StreamPortsRequest, PortService_StreamPortsServer) error
+	DeletePort(context.Context, *DeletePortRequest) (*emptypb.Empty, error)
+	RestorePort(context.Context, *RestorePortRequest) (*Port, error)
+	UpdatePort(context.Context, *UpdatePortRequest) (*Port, error)
+	FindNearbyPorts(context.Context, *FindNearbyPortsRequest) (*FindNearbyPortsResponse, error)
+	WatchPorts(*WatchPortsRequest, PortService_WatchPortsServer) error
+	GetPortHistory(context.Context, *GetPortHistoryRequest) (*GetPortHistoryResponse, error)
 	mustEmbedUnimplementedPortServiceServer()
 }
 
@@ -66,12 +269,48 @@ type PortServiceServer interface {
 type UnimplementedPortServiceServer struct {
 }
 
-func (UnimplementedPortServiceServer) StorePort(context.Context, *StorePortRequest) (*emptypb.Empty, error) {
+func (UnimplementedPortServiceServer) StorePort(context.Context, *StorePortRequest) (*StorePortResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StorePort not implemented")
 }
-func (UnimplementedPortServiceServer) ListPorts(context.Context, *emptypb.Empty) (*ListPortsResponse, error) {
+func (UnimplementedPortServiceServer) StorePorts(PortService_StorePortsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StorePorts not implemented")
+}
+func (UnimplementedPortServiceServer) ListPorts(context.Context, *ListPortsRequest) (*ListPortsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListPorts not implemented")
 }
+func (UnimplementedPortServiceServer) SearchPorts(context.Context, *SearchPortsRequest) (*SearchPortsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchPorts not implemented")
+}
+func (UnimplementedPortServiceServer) SuggestPorts(context.Context, *SuggestPortsRequest) (*SuggestPortsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuggestPorts not implemented")
+}
+func (UnimplementedPortServiceServer) GetPort(context.Context, *GetPortRequest) (*Port, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPort not implemented")
+}
+func (UnimplementedPortServiceServer) LookupPort(context.Context, *LookupPortRequest) (*Port, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupPort not implemented")
+}
+func (UnimplementedPortServiceServer) StreamPorts(*StreamPortsRequest, PortService_StreamPortsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamPorts not implemented")
+}
+func (UnimplementedPortServiceServer) DeletePort(context.Context, *DeletePortRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePort not implemented")
+}
+func (UnimplementedPortServiceServer) RestorePort(context.Context, *RestorePortRequest) (*Port, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestorePort not implemented")
+}
+func (UnimplementedPortServiceServer) UpdatePort(context.Context, *UpdatePortRequest) (*Port, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePort not implemented")
+}
+func (UnimplementedPortServiceServer) FindNearbyPorts(context.Context, *FindNearbyPortsRequest) (*FindNearbyPortsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindNearbyPorts not implemented")
+}
+func (UnimplementedPortServiceServer) WatchPorts(*WatchPortsRequest, PortService_WatchPortsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPorts not implemented")
+}
+func (UnimplementedPortServiceServer) GetPortHistory(context.Context, *GetPortHistoryRequest) (*GetPortHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPortHistory not implemented")
+}
 func (UnimplementedPortServiceServer) mustEmbedUnimplementedPortServiceServer() {}
 
 // UnsafePortServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -103,8 +342,34 @@ func _PortService_StorePort_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PortService_StorePorts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PortServiceServer).StorePorts(&portServiceStorePortsServer{stream})
+}
+
+type PortService_StorePortsServer interface {
+	SendAndClose(*StorePortsResponse) error
+	Recv() (*StorePortRequest, error)
+	grpc.ServerStream
+}
+
+type portServiceStorePortsServer struct {
+	grpc.ServerStream
+}
+
+func (x *portServiceStorePortsServer) SendAndClose(m *StorePortsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *portServiceStorePortsServer) Recv() (*StorePortRequest, error) {
+	m := new(StorePortRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _PortService_ListPorts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(ListPortsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -116,7 +381,211 @@ func _PortService_ListPorts_Handler(srv interface{}, ctx context.Context, dec fu
 		FullMethod: "/ports.PortService/ListPorts",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PortServiceServer).ListPorts(ctx, req.(*emptypb.Empty))
+		return srv.(PortServiceServer).ListPorts(ctx, req.(*ListPortsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_SearchPorts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchPortsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).SearchPorts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/SearchPorts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).SearchPorts(ctx, req.(*SearchPortsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_SuggestPorts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuggestPortsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).SuggestPorts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/SuggestPorts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).SuggestPorts(ctx, req.(*SuggestPortsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_GetPort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).GetPort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/GetPort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).GetPort(ctx, req.(*GetPortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_LookupPort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupPortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).LookupPort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/LookupPort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).LookupPort(ctx, req.(*LookupPortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_StreamPorts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamPortsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PortServiceServer).StreamPorts(m, &portServiceStreamPortsServer{stream})
+}
+
+type PortService_StreamPortsServer interface {
+	Send(*Port) error
+	grpc.ServerStream
+}
+
+type portServiceStreamPortsServer struct {
+	grpc.ServerStream
+}
+
+func (x *portServiceStreamPortsServer) Send(m *Port) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PortService_DeletePort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).DeletePort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/DeletePort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).DeletePort(ctx, req.(*DeletePortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_RestorePort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestorePortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).RestorePort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/RestorePort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).RestorePort(ctx, req.(*RestorePortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_UpdatePort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).UpdatePort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/UpdatePort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).UpdatePort(ctx, req.(*UpdatePortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_FindNearbyPorts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindNearbyPortsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).FindNearbyPorts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/FindNearbyPorts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).FindNearbyPorts(ctx, req.(*FindNearbyPortsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_WatchPorts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPortsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PortServiceServer).WatchPorts(m, &portServiceWatchPortsServer{stream})
+}
+
+type PortService_WatchPortsServer interface {
+	Send(*PortEvent) error
+	grpc.ServerStream
+}
+
+type portServiceWatchPortsServer struct {
+	grpc.ServerStream
+}
+
+func (x *portServiceWatchPortsServer) Send(m *PortEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PortService_GetPortHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPortHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).GetPortHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ports.PortService/GetPortHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).GetPortHistory(ctx, req.(*GetPortHistoryRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -136,7 +605,59 @@ var PortService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListPorts",
 			Handler:    _PortService_ListPorts_Handler,
 		},
+		{
+			MethodName: "SearchPorts",
+			Handler:    _PortService_SearchPorts_Handler,
+		},
+		{
+			MethodName: "SuggestPorts",
+			Handler:    _PortService_SuggestPorts_Handler,
+		},
+		{
+			MethodName: "GetPort",
+			Handler:    _PortService_GetPort_Handler,
+		},
+		{
+			MethodName: "LookupPort",
+			Handler:    _PortService_LookupPort_Handler,
+		},
+		{
+			MethodName: "DeletePort",
+			Handler:    _PortService_DeletePort_Handler,
+		},
+		{
+			MethodName: "RestorePort",
+			Handler:    _PortService_RestorePort_Handler,
+		},
+		{
+			MethodName: "UpdatePort",
+			Handler:    _PortService_UpdatePort_Handler,
+		},
+		{
+			MethodName: "FindNearbyPorts",
+			Handler:    _PortService_FindNearbyPorts_Handler,
+		},
+		{
+			MethodName: "GetPortHistory",
+			Handler:    _PortService_GetPortHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StorePorts",
+			Handler:       _PortService_StorePorts_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamPorts",
+			Handler:       _PortService_StreamPorts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchPorts",
+			Handler:       _PortService_WatchPorts_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "ports.proto",
 }